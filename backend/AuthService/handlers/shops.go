@@ -0,0 +1,94 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "net/http"
+    "strings"
+
+    "github.com/jackc/pgx/v4"
+)
+
+type Shop struct {
+    Username        string `json:"username"`
+    ShopName        string `json:"shop_name"`
+    ProfileImageURL string `json:"profile_image_url"`
+}
+
+// ==================== LIST SHOPS ====================
+
+// ListShopsHandler returns the public shop profile of every seller.
+func ListShopsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    rows, err := db.Query(context.Background(), `
+        SELECT username,
+               COALESCE(NULLIF(NULLIF(shop_name, 'none'), ''), username),
+               COALESCE(profile_image_url, '')
+        FROM users
+        WHERE role = 'seller' AND status = 'active'
+        ORDER BY username
+    `)
+    if err != nil {
+        log.Println("DB error (list shops):", err)
+        http.Error(w, "Failed to fetch shops", http.StatusInternalServerError)
+        return
+    }
+    defer rows.Close()
+
+    shops := []Shop{}
+    for rows.Next() {
+        var shop Shop
+        if err := rows.Scan(&shop.Username, &shop.ShopName, &shop.ProfileImageURL); err != nil {
+            log.Println("DB error (scan shop):", err)
+            http.Error(w, "Failed to fetch shops", http.StatusInternalServerError)
+            return
+        }
+        shops = append(shops, shop)
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(shops)
+}
+
+// ==================== GET SHOP ====================
+
+// GetShopHandler returns the public shop profile of a single seller,
+// addressed as /shops/{username}.
+func GetShopHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    username := strings.Trim(strings.TrimPrefix(r.URL.Path, "/shops/"), "/")
+    if username == "" || strings.Contains(username, "/") {
+        http.Error(w, "Shop not found", http.StatusNotFound)
+        return
+    }
+
+    var shop Shop
+    err := db.QueryRow(context.Background(), `
+        SELECT username,
+               COALESCE(NULLIF(NULLIF(shop_name, 'none'), ''), username),
+               COALESCE(profile_image_url, '')
+        FROM users
+        WHERE username = $1 AND role = 'seller' AND status = 'active'
+    `, username).Scan(&shop.Username, &shop.ShopName, &shop.ProfileImageURL)
+    if err == pgx.ErrNoRows {
+        http.Error(w, "Shop not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        log.Println("DB error (get shop):", err)
+        http.Error(w, "Failed to fetch shop", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(shop)
+}
@@ -49,6 +49,10 @@ func main() {
     // Register a Seller
     mux.HandleFunc("/apply-seller", handlers.ApplySellerHandler)
 
+    // Public shop profiles
+    mux.HandleFunc("/shops", handlers.ListShopsHandler)
+    mux.HandleFunc("/shops/", handlers.GetShopHandler)
+
     handlerWithCORS := enableCORS(loggingMiddleware(mux))
 
     log.Printf("AuthService is running on port %s...", port)
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis channels ProductService subscribes to.
+const (
+	ChannelProductCreated = "product_created"
+	ChannelProductUpdated = "product_updated"
+)
+
+// Event types carried in InventoryEvent.Type.
+const (
+	TypeProductCreated = "product_created"
+	TypeProductUpdated = "product_updated"
+	TypeVariantCreated = "variant_created"
+	TypeVariantUpdated = "variant_updated"
+	TypeStockChanged   = "stock_changed"
+
+	// TypeAvailabilityChanged is sent when reservations change a variant's
+	// available-to-sell without touching its on-hand stock.
+	TypeAvailabilityChanged = "availability_changed"
+)
+
+// InventoryEvent describes a change to a product or one of its variants.
+// Version is the row version after the change; consumers should ignore an
+// event whose version is older than one they have already applied.
+type InventoryEvent struct {
+	Type          string    `json:"type"`
+	ProductID     int       `json:"product_id"`
+	VariantID     int       `json:"variant_id,omitempty"`
+	StockQuantity *int      `json:"stock_quantity,omitempty"`
+	PreviousStock *int      `json:"previous_stock,omitempty"`
+	Available     *int      `json:"available_to_sell,omitempty"`
+	BasePrice     *float64  `json:"base_price,omitempty"`
+	PriceOverride *float64  `json:"price_override,omitempty"` // variant events; nil sells at base_price
+	PriceChanged  bool      `json:"price_changed,omitempty"`  // base_price or price_override moved
+	Listed        *bool     `json:"listed,omitempty"`
+	Version       int       `json:"version"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+var (
+	ctx    = context.Background()
+	client *redis.Client
+)
+
+func Init() {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	client = redis.NewClient(&redis.Options{
+		Addr: addr,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Printf("⚠️ Redis not reachable at %s: %v", addr, err)
+		return
+	}
+	log.Printf("✅ Connected to Redis at %s", addr)
+}
+
+// Publish sends event on channel as JSON.
+func Publish(channel string, event InventoryEvent) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Publish(ctx, channel, body).Err(); err != nil {
+		return err
+	}
+
+	log.Printf("📣 Published %s for product %d on %s", event.Type, event.ProductID, channel)
+	return nil
+}
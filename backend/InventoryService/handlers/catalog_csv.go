@@ -0,0 +1,560 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"auth"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+	"InventoryService/stock"
+)
+
+const (
+	maxImportBytes = 5 << 20
+	maxImportRows  = 5000
+)
+
+// catalogColumns is the CSV layout shared by import and export: one row per
+// variant, repeating the product columns. A product without variants is a
+// single row with empty variant columns.
+var catalogColumns = []string{
+	"product_sku", "name", "description", "category", "base_price", "image",
+	"variant_sku", "variant_name", "size", "color", "stock_quantity", "variant_image",
+	"price_override", "compare_at_price", "reorder_threshold",
+	"fulfilment_mode", "expected_ship_date", "backorder_limit",
+}
+
+var variantCSVColumns = []string{
+	"variant_sku", "variant_name", "size", "color", "stock_quantity", "variant_image",
+	"price_override", "compare_at_price", "reorder_threshold",
+	"fulfilment_mode", "expected_ship_date", "backorder_limit",
+}
+
+// RowError is a validation error tied to a CSV row (the header is row 1).
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ImportResult struct {
+	DryRun          bool       `json:"dry_run"`
+	Applied         bool       `json:"applied"`
+	Rows            int        `json:"rows"`
+	ProductsCreated int        `json:"products_created"`
+	ProductsUpdated int        `json:"products_updated"`
+	VariantsCreated int        `json:"variants_created"`
+	VariantsUpdated int        `json:"variants_updated"`
+	Errors          []RowError `json:"errors"`
+}
+
+type csvRow struct {
+	line   int
+	fields map[string]string
+}
+
+func (r csvRow) get(name string) string {
+	return strings.TrimSpace(r.fields[name])
+}
+
+// optional returns nil for an empty cell so that updates leave it unchanged.
+func (r csvRow) optional(name string) *string {
+	if v := r.get(name); v != "" {
+		return &v
+	}
+	return nil
+}
+
+func (r csvRow) float(name string, errs *[]RowError) *float64 {
+	v := r.get(name)
+	if v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		*errs = append(*errs, RowError{r.line, name, "must be a number"})
+		return nil
+	}
+	return &f
+}
+
+func (r csvRow) int(name string, errs *[]RowError) *int {
+	v := r.get(name)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		*errs = append(*errs, RowError{r.line, name, "must be a whole number"})
+		return nil
+	}
+	return &n
+}
+
+// productInput reads the product columns of a row.
+func (r csvRow) productInput(errs *[]RowError) ProductInput {
+	return ProductInput{
+		Name:        r.optional("name"),
+		Description: r.optional("description"),
+		Category:    r.optional("category"),
+		BasePrice:   r.float("base_price", errs),
+		Image:       r.optional("image"),
+	}
+}
+
+// variantInput reads the variant columns of a row; variant_sku is matched
+// by the caller.
+func (r csvRow) variantInput(errs *[]RowError) VariantInput {
+	return VariantInput{
+		VariantName:      r.optional("variant_name"),
+		Size:             r.optional("size"),
+		Color:            r.optional("color"),
+		StockQuantity:    r.int("stock_quantity", errs),
+		Image:            r.optional("variant_image"),
+		PriceOverride:    r.float("price_override", errs),
+		CompareAtPrice:   r.float("compare_at_price", errs),
+		ReorderThreshold: r.int("reorder_threshold", errs),
+		FulfilmentMode:   r.optional("fulfilment_mode"),
+		ExpectedShipDate: r.optional("expected_ship_date"),
+		BackorderLimit:   r.int("backorder_limit", errs),
+	}
+}
+
+func (r csvRow) hasVariant() bool {
+	for _, c := range variantCSVColumns {
+		if r.get(c) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// readCatalogCSV reads the upload from a multipart "file" field or the raw
+// request body.
+func readCatalogCSV(r *http.Request) ([]csvRow, error) {
+	var src io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing file: %w", err)
+		}
+		defer file.Close()
+		if ext := strings.ToLower(filepath.Ext(header.Filename)); ext == ".xlsx" || ext == ".xls" {
+			return nil, errors.New("spreadsheet uploads are not supported; save the sheet as CSV")
+		}
+		src = file
+	}
+
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %w", err)
+	}
+
+	known := map[string]bool{}
+	for _, c := range catalogColumns {
+		known[c] = true
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if !known[header[i]] {
+			return nil, fmt.Errorf("unknown column %q", h)
+		}
+	}
+
+	var rows []csvRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", line, err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("imports are limited to %d rows", maxImportRows)
+		}
+
+		row := csvRow{line: line, fields: map[string]string{}}
+		empty := true
+		for i, v := range record {
+			if i < len(header) {
+				row.fields[header[i]] = v
+				empty = empty && strings.TrimSpace(v) == ""
+			}
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// ImportCatalog handles POST /seller/products/import?apply=true. Rows are
+// matched to existing products and variants by SKU; rows without a
+// product_sku that share a name become one new product. Variants without a
+// variant_sku match on size and color or are created with a generated SKU;
+// an unknown variant_sku creates the variant under that SKU.
+//
+// The import always runs in a single transaction. Without apply=true, or
+// when any row is invalid, it is rolled back and only the report is
+// returned.
+func ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	apply := r.URL.Query().Get("apply") == "true"
+	if user.Username == "" {
+		http.Error(w, "Token has no username", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	rows, err := readCatalogCSV(r)
+	if err != nil {
+		http.Error(w, "Invalid CSV: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeProductError(w, err, "import catalog")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := importRows(ctx, tx, user, rows)
+	if err != nil {
+		writeProductError(w, err, "import catalog")
+		return
+	}
+	result.DryRun = !apply
+
+	if apply && len(result.Errors) == 0 {
+		if err := tx.Commit(ctx); err != nil {
+			writeProductError(w, err, "import catalog")
+			return
+		}
+		result.Applied = true
+		log.Printf("📦 Seller %d imported %d rows", user.ID, result.Rows)
+	}
+
+	status := http.StatusOK
+	if len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, result)
+}
+
+type importProduct struct {
+	id        int
+	basePrice float64
+	image     string
+	variants  map[string]*Variant // by variantKey
+}
+
+func importRows(ctx context.Context, tx pgx.Tx, user *auth.User, rows []csvRow) (*ImportResult, error) {
+	result := &ImportResult{Rows: len(rows), Errors: []RowError{}}
+
+	// Lock the seller's catalog so the report matches what gets applied.
+	bySKU := map[string]*importProduct{}
+	byID := map[int]*importProduct{}
+	prows, err := tx.Query(ctx, `
+		SELECT id, COALESCE(sku, ''), base_price::float8, image
+		FROM products WHERE seller_id = $1 ORDER BY id FOR UPDATE`, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for prows.Next() {
+		p := &importProduct{variants: map[string]*Variant{}}
+		var sku string
+		if err := prows.Scan(&p.id, &sku, &p.basePrice, &p.image); err != nil {
+			prows.Close()
+			return nil, err
+		}
+		bySKU[strings.ToLower(sku)] = p
+		byID[p.id] = p
+	}
+	prows.Close()
+	if err := prows.Err(); err != nil {
+		return nil, err
+	}
+
+	variantsBySKU := map[string]*Variant{}
+	for id, p := range byID {
+		variants, err := loadVariants(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		for i := range variants {
+			v := &variants[i]
+			p.variants[variantKey(v.Size, v.Color)] = v
+			variantsBySKU[strings.ToLower(v.SKU)] = v
+		}
+	}
+
+	// Group rows by product, keeping file order.
+	var order []string
+	groups := map[string][]csvRow{}
+	for _, row := range rows {
+		key := "new:" + strings.ToLower(row.get("name"))
+		if sku := row.get("product_sku"); sku != "" {
+			key = "sku:" + strings.ToLower(sku)
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	for _, key := range order {
+		group := groups[key]
+		first := group[0]
+
+		var rowErrs []RowError
+		in := first.productInput(&rowErrs)
+
+		p := bySKU[strings.TrimPrefix(key, "sku:")]
+		creating := strings.HasPrefix(key, "new:")
+		if !creating && p == nil {
+			result.Errors = append(result.Errors, RowError{first.line, "product_sku", "does not match any of your products"})
+			continue
+		}
+		for _, fe := range validateProduct(in, creating) {
+			rowErrs = append(rowErrs, RowError{first.line, fe.Field, fe.Message})
+		}
+		if len(rowErrs) > 0 {
+			result.Errors = append(result.Errors, rowErrs...)
+			continue
+		}
+
+		if creating {
+			var id int
+			err := inSavepoint(ctx, tx, func(sp pgx.Tx) (err error) {
+				id, err = insertProduct(ctx, sp, user, in)
+				return err
+			})
+			if rowErr, ok := importRowError(err, first.line); ok {
+				result.Errors = append(result.Errors, rowErr)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			p = &importProduct{id: id, basePrice: *in.BasePrice, image: trimmed(in.Image), variants: map[string]*Variant{}}
+			result.ProductsCreated++
+		} else {
+			err := inSavepoint(ctx, tx, func(sp pgx.Tx) error {
+				return updateProduct(ctx, sp, p.id, in)
+			})
+			if rowErr, ok := importRowError(err, first.line); ok {
+				result.Errors = append(result.Errors, rowErr)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if in.BasePrice != nil {
+				p.basePrice = *in.BasePrice
+			}
+			if in.Image != nil {
+				p.image = trimmed(in.Image)
+			}
+			result.ProductsUpdated++
+		}
+
+		for _, row := range group {
+			if !row.hasVariant() {
+				continue
+			}
+			errs, err := importVariant(ctx, tx, p, row, variantsBySKU, result, stock.SellerActor(user.ID))
+			if err != nil {
+				return nil, err
+			}
+			result.Errors = append(result.Errors, errs...)
+		}
+	}
+
+	return result, nil
+}
+
+func importVariant(ctx context.Context, tx pgx.Tx, p *importProduct, row csvRow, bySKU map[string]*Variant, result *ImportResult, actor string) ([]RowError, error) {
+	var errs []RowError
+	in := row.variantInput(&errs)
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	size, color := trimmed(in.Size), trimmed(in.Color)
+	key := variantKey(size, color)
+
+	var current *Variant
+	if sku := row.get("variant_sku"); sku != "" {
+		current = bySKU[strings.ToLower(sku)]
+		if current != nil && current.ProductID != p.id {
+			return []RowError{{row.line, "variant_sku", "belongs to a variant of another product"}}, nil
+		}
+		if current == nil {
+			// A new variant under the seller's own SKU.
+			in.SKU = &sku
+		}
+		if other := p.variants[key]; other != nil && (current == nil || other.ID != current.ID) {
+			return []RowError{{row.line, "size", fmt.Sprintf("variant %s already has this size and color", other.SKU)}}, nil
+		}
+	} else {
+		current = p.variants[key]
+	}
+
+	if current == nil {
+		for _, fe := range validateVariant(in, true, p.basePrice, "") {
+			errs = append(errs, RowError{row.line, fe.Field, fe.Message})
+		}
+		if len(errs) > 0 {
+			return errs, nil
+		}
+		var v *Variant
+		err := inSavepoint(ctx, tx, func(sp pgx.Tx) (err error) {
+			v, err = insertVariant(ctx, sp, p.id, p.image, in, stock.ReasonImport, actor)
+			return err
+		})
+		if rowErr, ok := importRowError(err, row.line); ok {
+			return []RowError{rowErr}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.variants[key] = v
+		bySKU[strings.ToLower(v.SKU)] = v
+		result.VariantsCreated++
+		return nil, nil
+	}
+
+	if in.StockQuantity != nil && *in.StockQuantity == current.StockQuantity {
+		// Unchanged, possibly below zero for a backordered variant.
+		in.StockQuantity = nil
+	}
+	for _, fe := range validateVariant(in.mergedWith(current), false, p.basePrice, "") {
+		errs = append(errs, RowError{row.line, fe.Field, fe.Message})
+	}
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	var v *Variant
+	err := inSavepoint(ctx, tx, func(sp pgx.Tx) (err error) {
+		v, err = updateVariant(ctx, sp, current.ID, size, color, in, stock.ReasonImport, actor)
+		return err
+	})
+	if rowErr, ok := importRowError(err, row.line); ok {
+		return []RowError{rowErr}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	delete(p.variants, variantKey(current.Size, current.Color))
+	p.variants[key] = v
+	bySKU[strings.ToLower(v.SKU)] = v
+	result.VariantsUpdated++
+	return nil, nil
+}
+
+// inSavepoint runs fn in a savepoint of tx, so a row that violates a
+// constraint is rolled back alone and the import can report the rest.
+func inSavepoint(ctx context.Context, tx pgx.Tx, fn func(pgx.Tx) error) error {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(sp); err != nil {
+		sp.Rollback(ctx)
+		return err
+	}
+	return sp.Commit(ctx)
+}
+
+// constraintRowErrors names the field and message of the constraints a
+// valid-looking row can still run into.
+var constraintRowErrors = map[string]RowError{
+	"products_sku_key":                        {Field: "product_sku", Message: "is already used by another product"},
+	"product_variants_seller_sku":             {Field: "variant_sku", Message: "is already used by another of your variants"},
+	"product_variants_compare_at_above_price": {Field: "compare_at_price", Message: "must be higher than the selling price"},
+	"products_base_price_below_compare_at":    {Field: "base_price", Message: "must be lower than the compare_at_price of variants without a price_override"},
+	"product_variants_stock_within_limit":     {Field: "stock_quantity", Message: "is below what fulfilment_mode and backorder_limit allow"},
+	"product_variants_preorder_ship_date":     {Field: "expected_ship_date", Message: "is required for preorders"},
+}
+
+// importRowError turns a constraint violation or invalid value from the
+// database into an error on the row that caused it.
+func importRowError(err error, line int) (RowError, bool) {
+	if errors.Is(err, errSKUTaken) {
+		return RowError{line, "variant_sku", "is already used by another of your variants"}, true
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return RowError{}, false
+	}
+	if known, ok := constraintRowErrors[pgErr.ConstraintName]; ok {
+		known.Row = line
+		return known, true
+	}
+	// Class 22 is invalid data, class 23 an integrity constraint.
+	if strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23") {
+		return RowError{line, pgErr.ColumnName, pgErr.Message}, true
+	}
+	return RowError{}, false
+}
+
+// ExportCatalog handles GET /seller/products/export and writes the seller's
+// catalog in the import layout.
+func ExportCatalog(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT COALESCE(p.sku, ''), p.name, COALESCE(p.description, ''), p.category, p.base_price::text, p.image,
+		       COALESCE(v.sku, ''), COALESCE(v.variant_name, ''), COALESCE(v.size, ''), COALESCE(v.color, ''),
+		       COALESCE(v.stock_quantity::text, ''), COALESCE(v.image, ''),
+		       COALESCE(v.price_override::text, ''), COALESCE(v.compare_at_price::text, ''),
+		       COALESCE(v.reorder_threshold::text, ''), COALESCE(v.fulfilment_mode, ''),
+		       COALESCE(v.expected_ship_date::text, ''), COALESCE(v.backorder_limit::text, '')
+		FROM products p
+		LEFT JOIN product_variants v ON v.product_id = p.id
+		WHERE p.seller_id = $1
+		ORDER BY p.id, v.id`, user.ID)
+	if err != nil {
+		writeProductError(w, err, "export catalog")
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="catalog.csv"`)
+
+	out := csv.NewWriter(w)
+	out.Write(catalogColumns)
+	record := make([]string, len(catalogColumns))
+	for rows.Next() {
+		dest := make([]interface{}, len(record))
+		for i := range record {
+			dest[i] = &record[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			// Headers are already sent; the truncated file is the best we can do.
+			log.Printf("❌ Failed to export catalog for seller %d: %v", user.ID, err)
+			break
+		}
+		out.Write(record)
+	}
+	out.Flush()
+}
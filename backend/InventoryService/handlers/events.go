@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+
+	"InventoryService/events"
+	"InventoryService/rabbitmq"
+	"InventoryService/stock"
+)
+
+// hasuraEvent is the body Hasura posts for the inventorydb event triggers.
+type hasuraEvent struct {
+	Event struct {
+		Op   string `json:"op"`
+		Data struct {
+			Old json.RawMessage `json:"old"`
+			New json.RawMessage `json:"new"`
+		} `json:"data"`
+	} `json:"event"`
+	Table struct {
+		Name string `json:"name"`
+	} `json:"table"`
+}
+
+type productRow struct {
+	ID        int     `json:"id"`
+	BasePrice float64 `json:"base_price"`
+	Listed    bool    `json:"listed"`
+	Version   int     `json:"version"`
+}
+
+type variantRow struct {
+	ID               int      `json:"id"`
+	ProductID        int      `json:"product_id"`
+	StockQuantity    int      `json:"stock_quantity"`
+	PriceOverride    *float64 `json:"price_override"`
+	ReorderThreshold int      `json:"reorder_threshold"`
+	Version          int      `json:"version"`
+}
+
+func samePrice(a, b *float64) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+// eventSecretHeader carries INVENTORY_EVENT_SECRET, set on the Hasura event
+// triggers that call InventoryEventHandler.
+const eventSecretHeader = "X-Event-Secret"
+
+// fromHasura reports whether r carries the event trigger secret. Without a
+// configured secret no request is trusted.
+func fromHasura(r *http.Request) bool {
+	secret := os.Getenv("INVENTORY_EVENT_SECRET")
+	got := r.Header.Get(eventSecretHeader)
+	return secret != "" && subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+// InventoryEventHandler turns Hasura row events on products and
+// product_variants into typed events on the Redis product channels. Only
+// Hasura's event triggers may call it.
+func InventoryEventHandler(w http.ResponseWriter, r *http.Request) {
+	if !fromHasura(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input hasuraEvent
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	channel, event, err := toInventoryEvent(input)
+	if err != nil {
+		log.Printf("❌ Failed to decode %s row: %v", input.Table.Name, err)
+		http.Error(w, "Invalid event data", http.StatusBadRequest)
+		return
+	}
+	if channel == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := events.Publish(channel, event); err != nil {
+		log.Printf("❌ Failed to publish %s: %v", event.Type, err)
+		// Non-2xx makes Hasura retry the delivery.
+		http.Error(w, "Failed to publish event", http.StatusInternalServerError)
+		return
+	}
+	if event.Type == events.TypeStockChanged {
+		if err := handleStockChange(r.Context(), input); err != nil {
+			log.Printf("❌ Failed to handle stock change of variant %d: %v", event.VariantID, err)
+			http.Error(w, "Failed to handle stock change", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Event published"))
+}
+
+func toInventoryEvent(input hasuraEvent) (string, events.InventoryEvent, error) {
+	op := input.Event.Op
+
+	switch input.Table.Name {
+	case "products":
+		var row productRow
+		if err := json.Unmarshal(input.Event.Data.New, &row); err != nil {
+			return "", events.InventoryEvent{}, err
+		}
+		event := events.InventoryEvent{
+			Type:      events.TypeProductUpdated,
+			ProductID: row.ID,
+			BasePrice: &row.BasePrice,
+			Listed:    &row.Listed,
+			Version:   row.Version,
+		}
+		if op == "INSERT" {
+			event.Type = events.TypeProductCreated
+			return events.ChannelProductCreated, event, nil
+		}
+		var old productRow
+		if len(input.Event.Data.Old) > 0 && json.Unmarshal(input.Event.Data.Old, &old) == nil {
+			event.PriceChanged = old.BasePrice != row.BasePrice
+		}
+		return events.ChannelProductUpdated, event, nil
+
+	case "product_variants":
+		var row variantRow
+		if err := json.Unmarshal(input.Event.Data.New, &row); err != nil {
+			return "", events.InventoryEvent{}, err
+		}
+		event := events.InventoryEvent{
+			Type:          events.TypeVariantUpdated,
+			ProductID:     row.ProductID,
+			VariantID:     row.ID,
+			StockQuantity: &row.StockQuantity,
+			PriceOverride: row.PriceOverride,
+			Version:       row.Version,
+		}
+		if op == "INSERT" {
+			event.Type = events.TypeVariantCreated
+			return events.ChannelProductUpdated, event, nil
+		}
+
+		var old variantRow
+		if len(input.Event.Data.Old) > 0 && json.Unmarshal(input.Event.Data.Old, &old) == nil {
+			if old.StockQuantity != row.StockQuantity {
+				event.Type = events.TypeStockChanged
+				event.PreviousStock = &old.StockQuantity
+			}
+			event.PriceChanged = !samePrice(old.PriceOverride, row.PriceOverride)
+		}
+		return events.ChannelProductUpdated, event, nil
+	}
+
+	return "", events.InventoryEvent{}, nil
+}
+
+// handleStockChange reacts to a variant's stock moving: a restock from
+// zero or below starts notifying its back-in-stock subscribers, and a
+// decrement across the reorder threshold raises an alert. Both are keyed
+// by row version, so a retried delivery does neither twice.
+func handleStockChange(ctx context.Context, input hasuraEvent) error {
+	var old, row variantRow
+	if err := json.Unmarshal(input.Event.Data.Old, &old); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(input.Event.Data.New, &row); err != nil {
+		return err
+	}
+
+	if old.StockQuantity <= 0 && row.StockQuantity > 0 {
+		return stock.OpenRestockWave(ctx, row.ID, row.Version)
+	}
+	return raiseStockAlert(ctx, old, row)
+}
+
+// raiseStockAlert records and publishes an alert when a stock decrement
+// crossed the variant's reorder threshold. A failed publish is only logged
+// since the daily digest still lists the variant.
+func raiseStockAlert(ctx context.Context, old, row variantRow) error {
+	kind := stock.ThresholdCrossed(old.StockQuantity, row.StockQuantity, row.ReorderThreshold)
+	if kind == "" {
+		return nil
+	}
+	alert, created, err := stock.RecordAlert(ctx, kind, row.ID, row.Version, row.StockQuantity, row.ReorderThreshold)
+	if err != nil || !created {
+		return err
+	}
+
+	if err := rabbitmq.PublishStockAlert(*alert); err != nil {
+		log.Printf("❌ Failed to publish %s for variant %d: %v", kind, row.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInventoryEventHandlerRejectsMissingSecret(t *testing.T) {
+	t.Setenv("INVENTORY_EVENT_SECRET", "s3cret")
+
+	for _, secret := range []string{"", "wrong"} {
+		req := httptest.NewRequest(http.MethodPost, "/events/inventory", strings.NewReader(`{}`))
+		if secret != "" {
+			req.Header.Set(eventSecretHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		InventoryEventHandler(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("secret %q: status %d, want 401", secret, rec.Code)
+		}
+	}
+}
+
+func TestFromHasura(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/events/inventory", nil)
+	req.Header.Set(eventSecretHeader, "s3cret")
+
+	t.Setenv("INVENTORY_EVENT_SECRET", "s3cret")
+	if !fromHasura(req) {
+		t.Error("request with the configured secret was rejected")
+	}
+
+	t.Setenv("INVENTORY_EVENT_SECRET", "")
+	if fromHasura(req) {
+		t.Error("request was accepted without a configured secret")
+	}
+}
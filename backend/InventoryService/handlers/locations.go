@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"auth"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+	"InventoryService/stock"
+)
+
+// LocationInput is the body of location create and update requests. Nil
+// fields are left unchanged on update.
+type LocationInput struct {
+	Name      *string `json:"name"`
+	Province  *string `json:"province"`
+	IsDefault *bool   `json:"is_default"`
+	Active    *bool   `json:"active"` // update only
+}
+
+type LocationStock struct {
+	VariantID   int    `json:"variant_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	SKU         string `json:"sku"`
+	Quantity    int    `json:"quantity"`
+}
+
+type TransferRequest struct {
+	VariantID      int     `json:"variant_id"`
+	FromLocationID int     `json:"from_location_id"`
+	ToLocationID   int     `json:"to_location_id"`
+	Quantity       int     `json:"quantity"`
+	Note           *string `json:"note"`
+}
+
+const locationColumns = `l.id, l.seller_id, l.name, COALESCE(l.province, ''), l.is_default, l.active,
+	COALESCE((SELECT SUM(quantity) FROM location_stock WHERE location_id = l.id), 0)::INTEGER,
+	l.created_at, l.updated_at`
+
+func scanLocation(row pgx.Row) (*stock.Location, error) {
+	var l stock.Location
+	err := row.Scan(&l.ID, &l.SellerID, &l.Name, &l.Province, &l.IsDefault, &l.Active,
+		&l.TotalStock, &l.CreatedAt, &l.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, stock.ErrLocationNotFound
+	}
+	return &l, err
+}
+
+func writeLocationError(w http.ResponseWriter, err error, action string) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, stock.ErrLocationNotFound):
+		http.Error(w, "Location not found", http.StatusNotFound)
+	case errors.Is(err, stock.ErrUnknownVariant):
+		http.Error(w, "Variant not found", http.StatusNotFound)
+	case errors.Is(err, stock.ErrLocationInUse):
+		http.Error(w, "Move the stock out of this location before deactivating it", http.StatusConflict)
+	case errors.Is(err, stock.ErrLocationShort):
+		http.Error(w, "Not enough stock at the source location", http.StatusConflict)
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		http.Error(w, "A location with this name already exists", http.StatusConflict)
+	default:
+		log.Printf("❌ Failed to %s: %v", action, err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+func validateLocation(in LocationInput, creating bool) []FieldError {
+	var errs []FieldError
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			errs = append(errs, FieldError{"name", "is required"})
+		} else if len(name) > maxNameLength {
+			errs = append(errs, FieldError{"name", "must be at most 200 characters"})
+		}
+	} else if creating {
+		errs = append(errs, FieldError{"name", "is required"})
+	}
+	if in.IsDefault != nil && !*in.IsDefault {
+		errs = append(errs, FieldError{"is_default", "can only be set; make another location the default instead"})
+	}
+	return errs
+}
+
+// ListLocations handles GET /seller/locations
+func ListLocations(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT `+locationColumns+` FROM locations l
+		WHERE l.seller_id = $1
+		ORDER BY l.is_default DESC, l.name`, user.ID)
+	if err != nil {
+		writeLocationError(w, err, "list locations")
+		return
+	}
+	defer rows.Close()
+
+	locations := []stock.Location{}
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			writeLocationError(w, err, "list locations")
+			return
+		}
+		locations = append(locations, *l)
+	}
+	if err := rows.Err(); err != nil {
+		writeLocationError(w, err, "list locations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, locations)
+}
+
+// CreateLocation handles POST /seller/locations. A seller's first location
+// becomes the default.
+func CreateLocation(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+
+	var in LocationInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if errs := validateLocation(in, true); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeLocationError(w, err, "create location")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	makeDefault := in.IsDefault != nil && *in.IsDefault
+	if makeDefault {
+		if err := clearDefaultLocation(ctx, tx, user.ID); err != nil {
+			writeLocationError(w, err, "create location")
+			return
+		}
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO locations (seller_id, name, province, is_default)
+		VALUES ($1, $2, NULLIF($3, ''), $4 OR NOT EXISTS (SELECT 1 FROM locations WHERE seller_id = $1 AND is_default))
+		RETURNING id`,
+		user.ID, trimmed(in.Name), trimmed(in.Province), makeDefault).Scan(&id)
+	if err != nil {
+		writeLocationError(w, err, "create location")
+		return
+	}
+
+	respondWithLocation(w, ctx, tx, id, user.ID, http.StatusCreated, "create location")
+}
+
+// UpdateLocation handles PUT /seller/locations/{id}. Deactivating is only
+// allowed for an empty location that is not the default.
+func UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	var in LocationInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if errs := validateLocation(in, false); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeLocationError(w, err, "update location")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanLocation(tx.QueryRow(ctx, `
+		SELECT `+locationColumns+` FROM locations l
+		WHERE l.id = $1 AND l.seller_id = $2
+		FOR UPDATE`, id, user.ID))
+	if err != nil {
+		writeLocationError(w, err, "update location")
+		return
+	}
+
+	if in.Active != nil && !*in.Active && current.Active {
+		if current.IsDefault || current.TotalStock > 0 {
+			writeLocationError(w, stock.ErrLocationInUse, "update location")
+			return
+		}
+	}
+	makeDefault := in.IsDefault != nil && *in.IsDefault && !current.IsDefault
+	if makeDefault {
+		if in.Active != nil && !*in.Active {
+			writeValidationErrors(w, []FieldError{{"is_default", "an inactive location cannot be the default"}})
+			return
+		}
+		if err := clearDefaultLocation(ctx, tx, user.ID); err != nil {
+			writeLocationError(w, err, "update location")
+			return
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE locations SET
+			name = COALESCE($2, name),
+			province = CASE WHEN $3::text IS NULL THEN province ELSE NULLIF($3, '') END,
+			is_default = is_default OR $4,
+			active = COALESCE($5, active),
+			updated_at = NOW()
+		WHERE id = $1`,
+		id, optionalTrimmed(in.Name), optionalTrimmed(in.Province), makeDefault, in.Active)
+	if err != nil {
+		writeLocationError(w, err, "update location")
+		return
+	}
+
+	respondWithLocation(w, ctx, tx, id, user.ID, http.StatusOK, "update location")
+}
+
+func clearDefaultLocation(ctx context.Context, tx pgx.Tx, sellerID int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE locations SET is_default = FALSE, updated_at = NOW()
+		WHERE seller_id = $1 AND is_default`, sellerID)
+	return err
+}
+
+func respondWithLocation(w http.ResponseWriter, ctx context.Context, tx pgx.Tx, id, sellerID, status int, action string) {
+	l, err := scanLocation(tx.QueryRow(ctx, `
+		SELECT `+locationColumns+` FROM locations l WHERE l.id = $1 AND l.seller_id = $2`, id, sellerID))
+	if err != nil {
+		writeLocationError(w, err, action)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeLocationError(w, err, action)
+		return
+	}
+	writeJSON(w, status, l)
+}
+
+// GetLocationStock handles GET /seller/locations/{id}/stock
+func GetLocationStock(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = db.Pool.QueryRow(r.Context(), `
+		SELECT EXISTS (SELECT 1 FROM locations WHERE id = $1 AND seller_id = $2)`, id, user.ID).Scan(&exists)
+	if err == nil && !exists {
+		err = stock.ErrLocationNotFound
+	}
+	if err != nil {
+		writeLocationError(w, err, "load location stock")
+		return
+	}
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT v.id, p.id, p.name, v.sku, ls.quantity
+		FROM location_stock ls
+		JOIN product_variants v ON v.id = ls.variant_id
+		JOIN products p ON p.id = v.product_id
+		WHERE ls.location_id = $1 AND ls.quantity > 0
+		ORDER BY p.name, v.id`, id)
+	if err != nil {
+		writeLocationError(w, err, "load location stock")
+		return
+	}
+	defer rows.Close()
+
+	items := []LocationStock{}
+	for rows.Next() {
+		var s LocationStock
+		if err := rows.Scan(&s.VariantID, &s.ProductID, &s.ProductName, &s.SKU, &s.Quantity); err != nil {
+			writeLocationError(w, err, "load location stock")
+			return
+		}
+		items = append(items, s)
+	}
+	if err := rows.Err(); err != nil {
+		writeLocationError(w, err, "load location stock")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+// SetLocationStock handles PUT /seller/locations/{id}/stock/{variantID}
+// with {"quantity": n}; the variant total changes by the difference.
+func SetLocationStock(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid location ID", http.StatusBadRequest)
+		return
+	}
+	variantID, err := pathID(r, "variantID")
+	if err != nil {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	var in struct {
+		Quantity *int `json:"quantity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if in.Quantity == nil || *in.Quantity < 0 {
+		writeValidationErrors(w, []FieldError{{"quantity", "must be zero or more"}})
+		return
+	}
+
+	err = stock.SetLocationQuantity(r.Context(), user.ID, id, variantID, *in.Quantity,
+		stock.ReasonAdjustment, stock.SellerActor(user.ID))
+	if err != nil {
+		writeLocationError(w, err, "set location stock")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{
+		"location_id": id,
+		"variant_id":  variantID,
+		"quantity":    *in.Quantity,
+	})
+}
+
+// CreateTransfer handles POST /seller/transfers
+func CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var errs []FieldError
+	if req.Quantity <= 0 {
+		errs = append(errs, FieldError{"quantity", "must be positive"})
+	}
+	if req.FromLocationID == req.ToLocationID {
+		errs = append(errs, FieldError{"to_location_id", "must differ from from_location_id"})
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	transfer, err := stock.TransferStock(r.Context(), user.ID, stock.Transfer{
+		VariantID:      req.VariantID,
+		FromLocationID: req.FromLocationID,
+		ToLocationID:   req.ToLocationID,
+		Quantity:       req.Quantity,
+		Actor:          stock.SellerActor(user.ID),
+		Note:           optionalTrimmed(req.Note),
+	})
+	if err != nil {
+		writeLocationError(w, err, "transfer stock")
+		return
+	}
+
+	log.Printf("🚚 Seller %d moved %d of variant %d from location %d to %d",
+		user.ID, transfer.Quantity, transfer.VariantID, transfer.FromLocationID, transfer.ToLocationID)
+	writeJSON(w, http.StatusCreated, transfer)
+}
+
+// ListTransfers handles GET /seller/transfers?variant_id=&page=&page_size=
+func ListTransfers(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	variantID, _ := strconv.Atoi(r.URL.Query().Get("variant_id"))
+	page, pageSize := parsePage(r)
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT id, variant_id, from_location_id, to_location_id, quantity, actor, note, created_at,
+		       COUNT(*) OVER ()
+		FROM stock_transfers
+		WHERE seller_id = $1 AND ($2 = 0 OR variant_id = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, user.ID, variantID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeLocationError(w, err, "list transfers")
+		return
+	}
+	defer rows.Close()
+
+	transfers := []stock.Transfer{}
+	total := 0
+	for rows.Next() {
+		var t stock.Transfer
+		if err := rows.Scan(&t.ID, &t.VariantID, &t.FromLocationID, &t.ToLocationID, &t.Quantity,
+			&t.Actor, &t.Note, &t.CreatedAt, &total); err != nil {
+			writeLocationError(w, err, "list transfers")
+			return
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		writeLocationError(w, err, "list transfers")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"transfers": transfers,
+	})
+}
+
+// ListAllocations handles GET /seller/allocations?order_id= and tells the
+// seller which location ships each line of the order.
+func ListAllocations(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	orderID, err := strconv.Atoi(r.URL.Query().Get("order_id"))
+	if err != nil || orderID <= 0 {
+		http.Error(w, "order_id is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT a.order_id, a.variant_id, a.location_id, a.quantity
+		FROM stock_allocations a
+		JOIN locations l ON l.id = a.location_id
+		WHERE a.order_id = $1 AND l.seller_id = $2
+		ORDER BY a.variant_id, a.location_id`, orderID, user.ID)
+	if err != nil {
+		writeLocationError(w, err, "list allocations")
+		return
+	}
+	defer rows.Close()
+
+	allocations := []stock.Allocation{}
+	for rows.Next() {
+		var a stock.Allocation
+		if err := rows.Scan(&a.OrderID, &a.VariantID, &a.LocationID, &a.Quantity); err != nil {
+			writeLocationError(w, err, "list allocations")
+			return
+		}
+		allocations = append(allocations, a)
+	}
+	if err := rows.Err(); err != nil {
+		writeLocationError(w, err, "list allocations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, allocations)
+}
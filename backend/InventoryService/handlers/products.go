@@ -0,0 +1,690 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+	"InventoryService/stock"
+)
+
+// Product and variant changes made here reach ProductService through the
+// Hasura event triggers on both tables, like edits made in the console.
+
+type Variant struct {
+	ID               int        `json:"id"`
+	ProductID        int        `json:"product_id"`
+	VariantName      string     `json:"variant_name"`
+	Size             string     `json:"size"`
+	Color            string     `json:"color"`
+	SKU              string     `json:"sku"`
+	StockQuantity    int        `json:"stock_quantity"`
+	ReorderThreshold int        `json:"reorder_threshold"`
+	FulfilmentMode   string     `json:"fulfilment_mode"`
+	ExpectedShipDate *time.Time `json:"expected_ship_date"`
+	BackorderLimit   int        `json:"backorder_limit"`
+	Image            string     `json:"image"`
+	PriceOverride    *float64   `json:"price_override"`
+	CompareAtPrice   *float64   `json:"compare_at_price"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type Product struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	BasePrice      float64   `json:"base_price"`
+	Image          string    `json:"image"`
+	Category       string    `json:"category"`
+	SKU            string    `json:"sku"`
+	Listed         bool      `json:"listed"`
+	SellerID       int       `json:"seller_id"`
+	SellerUsername string    `json:"seller_username"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Variants       []Variant `json:"product_variants"`
+}
+
+var (
+	errProductNotFound = errors.New("product not found")
+	errNotOwner        = errors.New("product belongs to another seller")
+	errVariantNotFound = errors.New("variant not found")
+)
+
+// querier is implemented by both the pool and a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+const productColumns = `id, name, COALESCE(description, ''), base_price::float8, image, category,
+	COALESCE(sku, ''), listed, seller_id, seller_username, created_at, updated_at`
+
+const variantColumns = `id, product_id, COALESCE(variant_name, ''), COALESCE(size, ''), COALESCE(color, ''),
+	sku, stock_quantity, reorder_threshold, fulfilment_mode, expected_ship_date, backorder_limit,
+	image, price_override::float8, compare_at_price::float8, created_at, updated_at`
+
+func scanProduct(row pgx.Row) (*Product, error) {
+	var p Product
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.BasePrice, &p.Image, &p.Category,
+		&p.SKU, &p.Listed, &p.SellerID, &p.SellerUsername, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errProductNotFound
+	}
+	return &p, err
+}
+
+func scanVariant(row pgx.Row) (*Variant, error) {
+	var v Variant
+	err := row.Scan(&v.ID, &v.ProductID, &v.VariantName, &v.Size, &v.Color, &v.SKU,
+		&v.StockQuantity, &v.ReorderThreshold, &v.FulfilmentMode, &v.ExpectedShipDate, &v.BackorderLimit, &v.Image, &v.PriceOverride, &v.CompareAtPrice, &v.CreatedAt, &v.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errVariantNotFound
+	}
+	return &v, err
+}
+
+func loadVariants(ctx context.Context, q querier, productID int) ([]Variant, error) {
+	rows, err := q.Query(ctx, `SELECT `+variantColumns+` FROM product_variants WHERE product_id = $1 ORDER BY id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []Variant{}
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, *v)
+	}
+	return variants, rows.Err()
+}
+
+// lockOwnedProduct loads a product with its variants and locks the product
+// row for the rest of tx, so concurrent variant writes see each other.
+func lockOwnedProduct(ctx context.Context, tx pgx.Tx, id int, sellerID int) (*Product, error) {
+	p, err := scanProduct(tx.QueryRow(ctx, `SELECT `+productColumns+` FROM products WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if p.SellerID != sellerID {
+		return nil, errNotOwner
+	}
+	if p.Variants, err = loadVariants(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func writeProductError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, errProductNotFound):
+		http.Error(w, "Product not found", http.StatusNotFound)
+	case errors.Is(err, errVariantNotFound), errors.Is(err, stock.ErrUnknownVariant):
+		http.Error(w, "Variant not found", http.StatusNotFound)
+	case errors.Is(err, errNotOwner):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.Is(err, errSKUTaken), isSKUConflict(err):
+		writeValidationErrors(w, []FieldError{{"sku", "is already used by another of your variants"}})
+	case errors.Is(err, stock.ErrLocationShort):
+		writeFieldErrors(w, http.StatusConflict, []FieldError{{"stock_quantity", "cannot be lowered by more than your locations hold; adjust their stock first"}})
+	case isConstraintViolation(err, "product_variants_compare_at_above_price"):
+		writeValidationErrors(w, []FieldError{{"compare_at_price", "must be higher than the selling price"}})
+	case isConstraintViolation(err, "products_base_price_below_compare_at"):
+		writeValidationErrors(w, []FieldError{{"base_price", "must be lower than the compare_at_price of variants without a price_override"}})
+	case isConstraintViolation(err, "product_variants_stock_within_limit"):
+		http.Error(w, "Stock is below zero; receive the backordered units before switching to in_stock or lowering backorder_limit", http.StatusConflict)
+	default:
+		log.Printf("❌ Failed to %s: %v", action, err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+func isConstraintViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514" && pgErr.ConstraintName == constraint
+}
+
+func pathID(r *http.Request, name string) (int, error) {
+	return strconv.Atoi(chi.URLParam(r, name))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func trimmed(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
+
+// ListSellerProducts handles GET /seller/products?listed=&page=&page_size=
+func ListSellerProducts(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	page, pageSize := parsePage(r)
+
+	listed := r.URL.Query().Get("listed")
+	if listed != "" && listed != "true" && listed != "false" {
+		http.Error(w, "listed must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+productColumns+`, COUNT(*) OVER ()
+		FROM products
+		WHERE seller_id = $1 AND ($2 = '' OR listed = ($2 = 'true'))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, user.ID, listed, pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeProductError(w, err, "list products")
+		return
+	}
+
+	products := []Product{}
+	total := 0
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.BasePrice, &p.Image, &p.Category,
+			&p.SKU, &p.Listed, &p.SellerID, &p.SellerUsername, &p.CreatedAt, &p.UpdatedAt, &total); err != nil {
+			rows.Close()
+			writeProductError(w, err, "list products")
+			return
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeProductError(w, err, "list products")
+		return
+	}
+
+	for i := range products {
+		if products[i].Variants, err = loadVariants(ctx, db.Pool, products[i].ID); err != nil {
+			writeProductError(w, err, "list products")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"products":  products,
+	})
+}
+
+// GetSellerProduct handles GET /seller/products/{id}
+func GetSellerProduct(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	p, err := scanProduct(db.Pool.QueryRow(r.Context(), `SELECT `+productColumns+` FROM products WHERE id = $1`, id))
+	if err == nil && p.SellerID != user.ID {
+		err = errNotOwner
+	}
+	if err == nil {
+		p.Variants, err = loadVariants(r.Context(), db.Pool, id)
+	}
+	if err != nil {
+		writeProductError(w, err, "fetch product")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// CreateProduct handles POST /seller/products. Products start unlisted; any
+// variants in the body are created in the same transaction.
+func CreateProduct(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	if user.Username == "" {
+		http.Error(w, "Token has no username", http.StatusUnauthorized)
+		return
+	}
+
+	var in ProductInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	errs := validateProduct(in, true)
+	seen := map[string]bool{}
+	for i, v := range in.Variants {
+		prefix := fmt.Sprintf("variants[%d].", i)
+		basePrice := 0.0
+		if in.BasePrice != nil {
+			basePrice = *in.BasePrice
+		}
+		errs = append(errs, validateVariant(v, true, basePrice, prefix)...)
+		key := variantKey(trimmed(v.Size), trimmed(v.Color))
+		if seen[key] {
+			errs = append(errs, FieldError{prefix + "size", "duplicates another variant's size and color"})
+		}
+		seen[key] = true
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeProductError(w, err, "create product")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := insertProduct(ctx, tx, user, in)
+	if err != nil {
+		writeProductError(w, err, "create product")
+		return
+	}
+
+	for _, v := range in.Variants {
+		if _, err := insertVariant(ctx, tx, id, trimmed(in.Image), v, stock.ReasonAdjustment, stock.SellerActor(user.ID)); err != nil {
+			writeProductError(w, err, "create product")
+			return
+		}
+	}
+
+	p, err := lockOwnedProduct(ctx, tx, id, user.ID)
+	if err != nil {
+		writeProductError(w, err, "create product")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeProductError(w, err, "create product")
+		return
+	}
+
+	log.Printf("🆕 Seller %d created product %d", user.ID, id)
+	writeJSON(w, http.StatusCreated, p)
+}
+
+// UpdateProduct handles PUT /seller/products/{id}
+func UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var in ProductInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(in.Variants) > 0 {
+		http.Error(w, "Variants are managed through /seller/products/{id}/variants", http.StatusBadRequest)
+		return
+	}
+	if errs := validateProduct(in, false); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeProductError(w, err, "update product")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := lockOwnedProduct(ctx, tx, id, user.ID)
+	if err != nil {
+		writeProductError(w, err, "update product")
+		return
+	}
+
+	// A lower base price must not undercut compare-at prices of variants
+	// that sell at the base price.
+	if in.BasePrice != nil {
+		for _, v := range p.Variants {
+			if v.PriceOverride == nil && v.CompareAtPrice != nil && *v.CompareAtPrice <= *in.BasePrice {
+				writeValidationErrors(w, []FieldError{{"base_price", fmt.Sprintf("must be lower than the compare-at price of variant %d", v.ID)}})
+				return
+			}
+		}
+	}
+
+	if err := updateProduct(ctx, tx, id, in); err != nil {
+		writeProductError(w, err, "update product")
+		return
+	}
+
+	respondWithProduct(w, ctx, tx, id, user.ID, "update product")
+}
+
+// SetProductListed returns the handler for POST /seller/products/{id}/list
+// and /unlist. A product needs at least one variant to be listed.
+func SetProductListed(listed bool) http.HandlerFunc {
+	action := "unlist product"
+	if listed {
+		action = "list product"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := auth.CurrentUser(r)
+		ctx := r.Context()
+		id, err := pathID(r, "id")
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := db.Pool.Begin(ctx)
+		if err != nil {
+			writeProductError(w, err, action)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		p, err := lockOwnedProduct(ctx, tx, id, user.ID)
+		if err != nil {
+			writeProductError(w, err, action)
+			return
+		}
+		if listed && len(p.Variants) == 0 {
+			writeValidationErrors(w, []FieldError{{"product_variants", "a product needs at least one variant to be listed"}})
+			return
+		}
+
+		if p.Listed != listed {
+			if _, err := tx.Exec(ctx, `UPDATE products SET listed = $2, updated_at = NOW() WHERE id = $1`, id, listed); err != nil {
+				writeProductError(w, err, action)
+				return
+			}
+		}
+
+		respondWithProduct(w, ctx, tx, id, user.ID, action)
+	}
+}
+
+// CreateVariant handles POST /seller/products/{id}/variants
+func CreateVariant(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var in VariantInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeProductError(w, err, "create variant")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := lockOwnedProduct(ctx, tx, id, user.ID)
+	if err != nil {
+		writeProductError(w, err, "create variant")
+		return
+	}
+
+	errs := validateVariant(in, true, p.BasePrice, "")
+	key := variantKey(trimmed(in.Size), trimmed(in.Color))
+	for _, v := range p.Variants {
+		if variantKey(v.Size, v.Color) == key {
+			errs = append(errs, FieldError{"size", fmt.Sprintf("variant %d already has this size and color", v.ID)})
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	variant, err := insertVariant(ctx, tx, id, p.Image, in, stock.ReasonAdjustment, stock.SellerActor(user.ID))
+	if err != nil {
+		writeProductError(w, err, "create variant")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeProductError(w, err, "create variant")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, variant)
+}
+
+// UpdateVariant handles PUT /seller/products/{id}/variants/{variantID}
+func UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	variantID, err := pathID(r, "variantID")
+	if err != nil {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	var in VariantInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeProductError(w, err, "update variant")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := lockOwnedProduct(ctx, tx, id, user.ID)
+	if err != nil {
+		writeProductError(w, err, "update variant")
+		return
+	}
+
+	var current *Variant
+	for i := range p.Variants {
+		if p.Variants[i].ID == variantID {
+			current = &p.Variants[i]
+		}
+	}
+	if current == nil {
+		writeProductError(w, errVariantNotFound, "update variant")
+		return
+	}
+
+	// Validate the variant as it will be after the update.
+	errs := validateVariant(in.mergedWith(current), false, p.BasePrice, "")
+
+	size, color := current.Size, current.Color
+	if in.Size != nil {
+		size = trimmed(in.Size)
+	}
+	if in.Color != nil {
+		color = trimmed(in.Color)
+	}
+	for _, v := range p.Variants {
+		if v.ID != variantID && variantKey(v.Size, v.Color) == variantKey(size, color) {
+			errs = append(errs, FieldError{"size", fmt.Sprintf("variant %d already has this size and color", v.ID)})
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	variant, err := updateVariant(ctx, tx, variantID, size, color, in, stock.ReasonAdjustment, stock.SellerActor(user.ID))
+	if err != nil {
+		writeProductError(w, err, "update variant")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeProductError(w, err, "update variant")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, variant)
+}
+
+// insertProduct adds a validated, unlisted product owned by user.
+func insertProduct(ctx context.Context, tx pgx.Tx, user *auth.User, in ProductInput) (int, error) {
+	var id int
+	err := tx.QueryRow(ctx, `
+		INSERT INTO products (name, description, base_price, image, category, seller_id, seller_username, listed)
+		VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'Uncategorized'), $6, $7, FALSE)
+		RETURNING id`,
+		trimmed(in.Name), trimmed(in.Description), *in.BasePrice, trimmed(in.Image), trimmed(in.Category),
+		user.ID, user.Username).Scan(&id)
+	return id, err
+}
+
+// updateProduct applies the non-nil fields of a validated input.
+func updateProduct(ctx context.Context, tx pgx.Tx, id int, in ProductInput) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE products SET
+			name = COALESCE($2, name),
+			description = COALESCE($3, description),
+			base_price = COALESCE($4, base_price),
+			image = COALESCE($5, image),
+			category = COALESCE(NULLIF($6, ''), category),
+			updated_at = NOW()
+		WHERE id = $1`,
+		id, optionalTrimmed(in.Name), optionalTrimmed(in.Description), in.BasePrice, optionalTrimmed(in.Image), trimmed(in.Category))
+	return err
+}
+
+// updateVariant applies the non-nil fields of a validated input; size and
+// color are passed resolved since an empty value clears them. An empty SKU
+// is regenerated from the seller's template. A stock change goes through
+// the ledger under reason.
+func updateVariant(ctx context.Context, tx pgx.Tx, variantID int, size, color string, in VariantInput, reason, actor string) (*Variant, error) {
+	var sku *string
+	if in.SKU != nil {
+		var productID int
+		if err := tx.QueryRow(ctx, `SELECT product_id FROM product_variants WHERE id = $1`, variantID).Scan(&productID); err != nil {
+			return nil, err
+		}
+		assigned, err := assignSKU(ctx, tx, productID, variantID, trimmed(in.SKU), size, color)
+		if err != nil {
+			return nil, err
+		}
+		sku = &assigned
+	}
+
+	if in.StockQuantity != nil {
+		if err := stock.SetQuantity(ctx, tx, variantID, *in.StockQuantity, reason, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	return scanVariant(tx.QueryRow(ctx, `
+		UPDATE product_variants SET
+			variant_name = COALESCE($2, variant_name),
+			size = NULLIF($3, ''),
+			color = NULLIF($4, ''),
+			image = COALESCE($5, image),
+			price_override = CASE WHEN $13 THEN NULL ELSE COALESCE($6, price_override) END,
+			compare_at_price = CASE WHEN $14 THEN NULL ELSE COALESCE($7, compare_at_price) END,
+			reorder_threshold = COALESCE($8, reorder_threshold),
+			fulfilment_mode = COALESCE($9, fulfilment_mode),
+			expected_ship_date = CASE WHEN $10::date IS NULL THEN expected_ship_date ELSE $10 END,
+			backorder_limit = COALESCE($11, backorder_limit),
+			sku = COALESCE($12, sku),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+variantColumns,
+		variantID, optionalTrimmed(in.VariantName), size, color,
+		optionalTrimmed(in.Image), in.PriceOverride, in.CompareAtPrice, in.ReorderThreshold,
+		in.FulfilmentMode, in.ExpectedShipDate, in.BackorderLimit, sku,
+		in.ClearPriceOverride, in.ClearCompareAtPrice))
+}
+
+// insertVariant adds a validated variant; without a SKU one is generated
+// from the seller's template and the image defaults to the product image.
+// The variant starts empty and its opening stock is recorded in the ledger
+// under reason.
+func insertVariant(ctx context.Context, tx pgx.Tx, productID int, productImage string, in VariantInput, reason, actor string) (*Variant, error) {
+	image := trimmed(in.Image)
+	if image == "" {
+		image = productImage
+	}
+	sku, err := assignSKU(ctx, tx, productID, 0, trimmed(in.SKU), trimmed(in.Size), trimmed(in.Color))
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO product_variants
+			(product_id, variant_name, size, color, sku, stock_quantity, image, price_override, compare_at_price,
+			 reorder_threshold, fulfilment_mode, expected_ship_date, backorder_limit)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5, 0, $6, $7, $8,
+			COALESCE($9, 0), COALESCE($10, 'in_stock'), $11, COALESCE($12, 0))
+		RETURNING id`,
+		productID, trimmed(in.VariantName), trimmed(in.Size), trimmed(in.Color), sku,
+		image, in.PriceOverride, in.CompareAtPrice, in.ReorderThreshold,
+		in.FulfilmentMode, in.ExpectedShipDate, in.BackorderLimit).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	if err := stock.SetQuantity(ctx, tx, id, *in.StockQuantity, reason, actor); err != nil {
+		return nil, err
+	}
+
+	return scanVariant(tx.QueryRow(ctx, `SELECT `+variantColumns+` FROM product_variants WHERE id = $1`, id))
+}
+
+func respondWithProduct(w http.ResponseWriter, ctx context.Context, tx pgx.Tx, id, sellerID int, action string) {
+	p, err := lockOwnedProduct(ctx, tx, id, sellerID)
+	if err != nil {
+		writeProductError(w, err, action)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeProductError(w, err, action)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func optionalTrimmed(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	t := strings.TrimSpace(*s)
+	return &t
+}
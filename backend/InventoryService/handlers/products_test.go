@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"InventoryService/stock"
+)
+
+func TestWriteProductErrorStockErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("set quantity: %w", stock.ErrLocationShort), http.StatusConflict},
+		{stock.ErrUnknownVariant, http.StatusNotFound},
+		{errSKUTaken, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeProductError(rec, tt.err, "update variant")
+		if rec.Code != tt.want {
+			t.Errorf("%v: status %d, want %d", tt.err, rec.Code, tt.want)
+		}
+	}
+}
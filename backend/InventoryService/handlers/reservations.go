@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"auth"
+
+	"InventoryService/stock"
+)
+
+const maxReservationTTL = time.Hour
+
+type ReservationRequest struct {
+	Items      []stock.OrderItem `json:"items"`
+	TTLMinutes int               `json:"ttl_minutes"`
+}
+
+func writeReservationError(w http.ResponseWriter, err error, action string) {
+	var short *stock.InsufficientStockError
+	switch {
+	case errors.As(err, &short):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error": "insufficient_stock",
+			"items": short.Shortages,
+		})
+	case errors.Is(err, stock.ErrUnknownVariant):
+		http.Error(w, "Unknown variant", http.StatusBadRequest)
+	case errors.Is(err, stock.ErrReservationNotFound):
+		http.Error(w, "Reservation not found", http.StatusNotFound)
+	case errors.Is(err, stock.ErrReservationClosed):
+		http.Error(w, "Reservation is no longer active", http.StatusConflict)
+	case errors.Is(err, stock.ErrReservationLimit):
+		http.Error(w, fmt.Sprintf("Release a reservation first: at most %d active reservations of %d units in total are allowed", stock.MaxActiveReservations, stock.MaxReservedUnits), http.StatusTooManyRequests)
+	default:
+		log.Printf("❌ Failed to %s: %v", action, err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+// CreateReservation handles POST /reservations. Checkout calls it before
+// placing the order and passes the reservation ID to OrderService.
+func CreateReservation(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var req ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "Reservation must contain at least one item", http.StatusBadRequest)
+		return
+	}
+	for _, item := range req.Items {
+		if item.VariantID <= 0 || item.Quantity <= 0 {
+			http.Error(w, "Items need a variant_id and a positive quantity", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ttl := stock.DefaultReservationTTL
+	if req.TTLMinutes > 0 {
+		ttl = time.Duration(req.TTLMinutes) * time.Minute
+	}
+	if ttl > maxReservationTTL {
+		ttl = maxReservationTTL
+	}
+
+	reservation, err := stock.Reserve(r.Context(), user.ID, req.Items, ttl)
+	if err != nil {
+		writeReservationError(w, err, "reserve stock")
+		return
+	}
+
+	log.Printf("🔒 Reservation %d for buyer %d expires at %s", reservation.ID, user.ID, reservation.ExpiresAt.Format(time.RFC3339))
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+// GetReservation handles GET /reservations/{id}
+func GetReservation(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := stock.GetReservation(r.Context(), id, auth.CurrentUser(r).ID)
+	if err != nil {
+		writeReservationError(w, err, "fetch reservation")
+		return
+	}
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// ReleaseReservation handles DELETE /reservations/{id}, e.g. when the buyer
+// abandons checkout.
+func ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := stock.Release(r.Context(), id, auth.CurrentUser(r).ID); err != nil {
+		writeReservationError(w, err, "release reservation")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
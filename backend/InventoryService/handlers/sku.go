@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"auth"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+)
+
+// Variant SKUs are generated from the seller's template when a variant is
+// created without one. Placeholders are replaced by uppercase codes:
+//
+//	{shop}        seller username
+//	{product}     product name
+//	{product_id}  product ID
+//	{size}        variant size
+//	{color}       variant color
+//	{seq}         next number in the seller's SKU sequence, 4 digits
+//
+// Empty placeholders are dropped along with the separator before them. A
+// SKU already used in the seller's catalog gets -2, -3, ... appended.
+const defaultSKUTemplate = "{shop}-{product}-{size}-{color}"
+
+const (
+	maxSKULength         = 64
+	maxSKUTemplateLength = 100
+	maxSKUCodeLength     = 12
+)
+
+var (
+	errSKUTaken = errors.New("sku already used in the seller's catalog")
+
+	skuPattern         = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	skuPlaceholder     = regexp.MustCompile(`\{[a-z_]*\}`)
+	skuTemplateLiteral = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+	skuCodeInvalid     = regexp.MustCompile(`[^A-Z0-9]+`)
+	skuSeparatorRun    = regexp.MustCompile(`([._-])[._-]+`)
+
+	skuPlaceholders = map[string]bool{
+		"{shop}": true, "{product}": true, "{product_id}": true,
+		"{size}": true, "{color}": true, "{seq}": true,
+	}
+)
+
+// SKUTemplate is the body of GET and PUT /seller/sku-template.
+type SKUTemplate struct {
+	Template string `json:"template"`
+	Example  string `json:"example,omitempty"`
+}
+
+// skuCode reduces a value to uppercase letters and digits.
+func skuCode(s string, max int) string {
+	code := skuCodeInvalid.ReplaceAllString(strings.ToUpper(s), "")
+	if len(code) > max {
+		code = code[:max]
+	}
+	return code
+}
+
+// renderSKU fills in a template and tidies the separators left around
+// empty placeholders.
+func renderSKU(template string, values map[string]string) string {
+	sku := skuPlaceholder.ReplaceAllStringFunc(template, func(p string) string {
+		return values[p]
+	})
+	sku = skuSeparatorRun.ReplaceAllString(strings.ToUpper(sku), "$1")
+	sku = strings.Trim(sku, "._-")
+	if len(sku) > maxSKULength-4 {
+		// Leave room for a collision suffix.
+		sku = strings.TrimRight(sku[:maxSKULength-4], "._-")
+	}
+	return sku
+}
+
+func validateSKUTemplate(template string) []FieldError {
+	if template == "" {
+		return []FieldError{{"template", "is required"}}
+	}
+	if len(template) > maxSKUTemplateLength {
+		return []FieldError{{"template", "must be at most 100 characters"}}
+	}
+
+	var errs []FieldError
+	for _, p := range skuPlaceholder.FindAllString(template, -1) {
+		if !skuPlaceholders[p] {
+			errs = append(errs, FieldError{"template", "has unknown placeholder " + p})
+		}
+	}
+	if !skuTemplateLiteral.MatchString(skuPlaceholder.ReplaceAllString(template, "")) {
+		errs = append(errs, FieldError{"template", "may only contain placeholders, letters, digits, '.', '_' and '-'"})
+	}
+	if !skuPlaceholder.MatchString(template) {
+		errs = append(errs, FieldError{"template", "must contain at least one placeholder"})
+	}
+	return errs
+}
+
+// validateSKU checks a seller-chosen SKU; an empty one asks for a
+// generated SKU.
+func validateSKU(sku *string, prefix string) []FieldError {
+	if sku == nil || trimmed(sku) == "" {
+		return nil
+	}
+	s := trimmed(sku)
+	if len(s) > maxSKULength {
+		return []FieldError{{prefix + "sku", "must be at most 64 characters"}}
+	}
+	if !skuPattern.MatchString(s) {
+		return []FieldError{{prefix + "sku", "must start with a letter or digit and contain only letters, digits, '.', '_' and '-'"}}
+	}
+	return nil
+}
+
+func sellerSKUTemplate(ctx context.Context, q querier, sellerID int) (string, error) {
+	var template string
+	err := q.QueryRow(ctx, `SELECT template FROM seller_sku_settings WHERE seller_id = $1`, sellerID).Scan(&template)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultSKUTemplate, nil
+	}
+	return template, err
+}
+
+// lockSellerSKUs serialises SKU assignment within a seller's catalog for
+// the rest of tx and returns the seller's sequence, advanced by one when
+// next is set.
+func lockSellerSKUs(ctx context.Context, tx pgx.Tx, sellerID int, next bool) (int, error) {
+	step := 0
+	if next {
+		step = 1
+	}
+	var seq int
+	err := tx.QueryRow(ctx, `
+		INSERT INTO sku_sequences (seller_id, last_value) VALUES ($1, $2)
+		ON CONFLICT (seller_id) DO UPDATE SET last_value = sku_sequences.last_value + $2
+		RETURNING last_value`, sellerID, step).Scan(&seq)
+	return seq, err
+}
+
+func skuInUse(ctx context.Context, tx pgx.Tx, sellerID int, sku string, exceptVariantID int) (bool, error) {
+	var used bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM product_variants
+			WHERE seller_id = $1 AND LOWER(sku) = LOWER($2) AND id <> $3
+		)`, sellerID, sku, exceptVariantID).Scan(&used)
+	return used, err
+}
+
+// assignSKU returns the SKU a variant of productID should get: the
+// seller's own if requested is set, otherwise one generated from the
+// seller's template. exceptVariantID is the variant being updated, if any.
+func assignSKU(ctx context.Context, tx pgx.Tx, productID, exceptVariantID int, requested, size, color string) (string, error) {
+	var sellerID int
+	var name, username string
+	err := tx.QueryRow(ctx, `SELECT seller_id, name, seller_username FROM products WHERE id = $1`, productID).
+		Scan(&sellerID, &name, &username)
+	if err != nil {
+		return "", err
+	}
+
+	if requested != "" {
+		if _, err := lockSellerSKUs(ctx, tx, sellerID, false); err != nil {
+			return "", err
+		}
+		used, err := skuInUse(ctx, tx, sellerID, requested, exceptVariantID)
+		if err != nil {
+			return "", err
+		}
+		if used {
+			return "", errSKUTaken
+		}
+		return requested, nil
+	}
+
+	template, err := sellerSKUTemplate(ctx, tx, sellerID)
+	if err != nil {
+		return "", err
+	}
+	seq, err := lockSellerSKUs(ctx, tx, sellerID, strings.Contains(template, "{seq}"))
+	if err != nil {
+		return "", err
+	}
+	base := renderSKU(template, map[string]string{
+		"{shop}":       skuCode(username, maxSKUCodeLength),
+		"{product}":    skuCode(name, maxSKUCodeLength),
+		"{product_id}": strconv.Itoa(productID),
+		"{size}":       skuCode(size, maxSKUCodeLength),
+		"{color}":      skuCode(color, maxSKUCodeLength),
+		"{seq}":        fmt.Sprintf("%04d", seq),
+	})
+	if base == "" {
+		base = "SKU-" + strconv.Itoa(productID)
+	}
+
+	sku := base
+	for n := 2; ; n++ {
+		used, err := skuInUse(ctx, tx, sellerID, sku, exceptVariantID)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return sku, nil
+		}
+		sku = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// isSKUConflict reports a concurrent write of the same SKU that got past
+// assignSKU, e.g. from the Hasura console.
+func isSKUConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "product_variants_seller_sku"
+}
+
+// GetSKUTemplate handles GET /seller/sku-template
+func GetSKUTemplate(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	template, err := sellerSKUTemplate(r.Context(), db.Pool, user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to load SKU template for seller %d: %v", user.ID, err)
+		http.Error(w, "Failed to load SKU template", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SKUTemplate{Template: template, Example: exampleSKU(template, user.Username)})
+}
+
+// SetSKUTemplate handles PUT /seller/sku-template. Existing SKUs are kept;
+// the template applies to variants created afterwards.
+func SetSKUTemplate(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	var in SKUTemplate
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	in.Template = strings.TrimSpace(in.Template)
+	if errs := validateSKUTemplate(in.Template); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	_, err := db.Pool.Exec(r.Context(), `
+		INSERT INTO seller_sku_settings (seller_id, template) VALUES ($1, $2)
+		ON CONFLICT (seller_id) DO UPDATE SET template = EXCLUDED.template, updated_at = NOW()`,
+		user.ID, in.Template)
+	if err != nil {
+		log.Printf("❌ Failed to save SKU template for seller %d: %v", user.ID, err)
+		http.Error(w, "Failed to save SKU template", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🏷️ Seller %d set SKU template %q", user.ID, in.Template)
+	writeJSON(w, http.StatusOK, SKUTemplate{Template: in.Template, Example: exampleSKU(in.Template, user.Username)})
+}
+
+// exampleSKU renders template for a sample variant so sellers can preview it.
+func exampleSKU(template, username string) string {
+	return renderSKU(template, map[string]string{
+		"{shop}":       skuCode(username, maxSKUCodeLength),
+		"{product}":    "CLASSICTEE",
+		"{product_id}": "123",
+		"{size}":       "M",
+		"{color}":      "BLACK",
+		"{seq}":        "0001",
+	})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auth"
+
+	"InventoryService/db"
+	"InventoryService/stock"
+)
+
+type StockMovement struct {
+	ID          int64     `json:"id"`
+	VariantID   int       `json:"variant_id"`
+	VariantSKU  string    `json:"variant_sku"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Delta       int       `json:"delta"`
+	Reason      string    `json:"reason"`
+	ReferenceID *string   `json:"reference_id"`
+	Actor       string    `json:"actor"`
+	Note        *string   `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ListStockMovements handles GET
+// /seller/stock/movements?product_id=&variant_id=&reason=&page=&page_size=
+// and returns the seller's ledger, newest first.
+func ListStockMovements(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	query := r.URL.Query()
+	productID, _ := strconv.Atoi(query.Get("product_id"))
+	variantID, _ := strconv.Atoi(query.Get("variant_id"))
+	page, pageSize := parsePage(r)
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT m.id, m.variant_id, v.sku, p.id, p.name, m.delta, m.reason, m.reference_id,
+		       m.actor, m.note, m.created_at, COUNT(*) OVER ()
+		FROM stock_movements m
+		JOIN product_variants v ON v.id = m.variant_id
+		JOIN products p ON p.id = v.product_id
+		WHERE p.seller_id = $1
+		  AND ($2 = 0 OR p.id = $2)
+		  AND ($3 = 0 OR v.id = $3)
+		  AND ($4 = '' OR m.reason = $4)
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $5 OFFSET $6`,
+		user.ID, productID, variantID, query.Get("reason"), pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("❌ Failed to list stock movements: %v", err)
+		http.Error(w, "Failed to list stock movements", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	movements := []StockMovement{}
+	total := 0
+	for rows.Next() {
+		var m StockMovement
+		if err := rows.Scan(&m.ID, &m.VariantID, &m.VariantSKU, &m.ProductID, &m.ProductName, &m.Delta,
+			&m.Reason, &m.ReferenceID, &m.Actor, &m.Note, &m.CreatedAt, &total); err != nil {
+			log.Printf("❌ Failed to scan stock movement: %v", err)
+			http.Error(w, "Failed to list stock movements", http.StatusInternalServerError)
+			return
+		}
+		movements = append(movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Failed to read stock movements: %v", err)
+		http.Error(w, "Failed to list stock movements", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"movements": movements,
+	})
+}
+
+type StockDiscrepancy struct {
+	VariantID       int       `json:"variant_id"`
+	VariantSKU      string    `json:"variant_sku"`
+	ProductID       int       `json:"product_id"`
+	SellerID        int       `json:"seller_id"`
+	StockQuantity   int       `json:"stock_quantity"`
+	LedgerTotal     int       `json:"ledger_total"`
+	FirstDetectedAt time.Time `json:"first_detected_at"`
+	LastCheckedAt   time.Time `json:"last_checked_at"`
+}
+
+// ListStockDiscrepancies handles GET /admin/stock/discrepancies with the
+// variants flagged by the last reconciliation run.
+func ListStockDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT d.variant_id, v.sku, p.id, p.seller_id, d.stock_quantity, d.ledger_total,
+		       d.first_detected_at, d.last_checked_at
+		FROM stock_discrepancies d
+		JOIN product_variants v ON v.id = d.variant_id
+		JOIN products p ON p.id = v.product_id
+		ORDER BY d.first_detected_at, d.variant_id`)
+	if err != nil {
+		log.Printf("❌ Failed to list stock discrepancies: %v", err)
+		http.Error(w, "Failed to list stock discrepancies", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	discrepancies := []StockDiscrepancy{}
+	for rows.Next() {
+		var d StockDiscrepancy
+		if err := rows.Scan(&d.VariantID, &d.VariantSKU, &d.ProductID, &d.SellerID, &d.StockQuantity,
+			&d.LedgerTotal, &d.FirstDetectedAt, &d.LastCheckedAt); err != nil {
+			log.Printf("❌ Failed to scan stock discrepancy: %v", err)
+			http.Error(w, "Failed to list stock discrepancies", http.StatusInternalServerError)
+			return
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Failed to read stock discrepancies: %v", err)
+		http.Error(w, "Failed to list stock discrepancies", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, discrepancies)
+}
+
+type LocationDiscrepancy struct {
+	OrderID    int       `json:"order_id"`
+	VariantID  int       `json:"variant_id"`
+	VariantSKU string    `json:"variant_sku"`
+	SellerID   int       `json:"seller_id"`
+	LocationID int       `json:"location_id"`
+	Missing    int       `json:"missing"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// ListLocationDiscrepancies handles GET /admin/stock/location-discrepancies
+// with the order lines allocated to a default location because the
+// seller's locations held less than the variant's total.
+func ListLocationDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT d.order_id, d.variant_id, v.sku, p.seller_id, d.location_id, d.missing, d.detected_at
+		FROM location_discrepancies d
+		JOIN product_variants v ON v.id = d.variant_id
+		JOIN products p ON p.id = v.product_id
+		ORDER BY d.detected_at DESC, d.order_id, d.variant_id`)
+	if err != nil {
+		log.Printf("❌ Failed to list location discrepancies: %v", err)
+		http.Error(w, "Failed to list location discrepancies", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	discrepancies := []LocationDiscrepancy{}
+	for rows.Next() {
+		var d LocationDiscrepancy
+		if err := rows.Scan(&d.OrderID, &d.VariantID, &d.VariantSKU, &d.SellerID, &d.LocationID,
+			&d.Missing, &d.DetectedAt); err != nil {
+			log.Printf("❌ Failed to scan location discrepancy: %v", err)
+			http.Error(w, "Failed to list location discrepancies", http.StatusInternalServerError)
+			return
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Failed to read location discrepancies: %v", err)
+		http.Error(w, "Failed to list location discrepancies", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, discrepancies)
+}
+
+// ListLowStock handles GET /seller/stock/low and lists the seller's
+// variants at or below their reorder threshold, fastest selling first.
+func ListLowStock(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+
+	items, err := stock.LowStock(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to list low-stock variants: %v", err)
+		http.Error(w, "Failed to list low-stock variants", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"auth"
+
+	"InventoryService/stock"
+)
+
+func writeSubscriptionError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, stock.ErrUnknownVariant):
+		http.Error(w, "Variant not found", http.StatusNotFound)
+	case errors.Is(err, stock.ErrInStock):
+		http.Error(w, "Variant is in stock", http.StatusConflict)
+	case errors.Is(err, stock.ErrSubscriptionNotFound):
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+	default:
+		log.Printf("❌ Failed to %s: %v", action, err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+// ListRestockSubscriptions handles GET /restock-subscriptions
+func ListRestockSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := stock.Subscriptions(r.Context(), auth.CurrentUser(r).ID)
+	if err != nil {
+		writeSubscriptionError(w, err, "list subscriptions")
+		return
+	}
+	writeJSON(w, http.StatusOK, subscriptions)
+}
+
+// Subscribe handles POST /restock-subscriptions with {"variant_id": n}.
+// Only variants that cannot be bought right now accept subscribers.
+func Subscribe(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		VariantID int `json:"variant_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.VariantID <= 0 {
+		http.Error(w, "variant_id is required", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := stock.Subscribe(r.Context(), auth.CurrentUser(r).ID, in.VariantID)
+	if err != nil {
+		writeSubscriptionError(w, err, "subscribe")
+		return
+	}
+	writeJSON(w, http.StatusCreated, subscription)
+}
+
+// Unsubscribe handles DELETE /restock-subscriptions/{variantID}
+func Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	variantID, err := pathID(r, "variantID")
+	if err != nil {
+		http.Error(w, "Invalid variant ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := stock.Unsubscribe(r.Context(), auth.CurrentUser(r).ID, variantID); err != nil {
+		writeSubscriptionError(w, err, "unsubscribe")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
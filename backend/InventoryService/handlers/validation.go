@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"InventoryService/stock"
+)
+
+// dateLayout is the format of calendar dates in requests and CSV files.
+const dateLayout = "2006-01-02"
+
+const (
+	maxNameLength        = 200
+	maxDescriptionLength = 5000
+	maxPrice             = 1000000
+)
+
+// FieldError describes one invalid field in a seller request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProductInput is the body of product create and update requests. Nil
+// fields are left unchanged on update.
+type ProductInput struct {
+	Name        *string        `json:"name"`
+	Description *string        `json:"description"`
+	BasePrice   *float64       `json:"base_price"`
+	Image       *string        `json:"image"`
+	Category    *string        `json:"category"`
+	Variants    []VariantInput `json:"variants"` // create only
+}
+
+// VariantInput is the body of variant create and update requests. Nil
+// fields are left unchanged on update.
+type VariantInput struct {
+	VariantName      *string  `json:"variant_name"`
+	SKU              *string  `json:"sku"` // empty to generate from the seller's template
+	Size             *string  `json:"size"`
+	Color            *string  `json:"color"`
+	StockQuantity    *int     `json:"stock_quantity"`
+	Image            *string  `json:"image"`
+	PriceOverride    *float64 `json:"price_override"`
+	CompareAtPrice   *float64 `json:"compare_at_price"`
+	ReorderThreshold *int     `json:"reorder_threshold"`
+	FulfilmentMode   *string  `json:"fulfilment_mode"`
+	ExpectedShipDate *string  `json:"expected_ship_date"` // YYYY-MM-DD
+	BackorderLimit   *int     `json:"backorder_limit"`
+
+	// Update only: sell at the base price again, or drop the "was" price.
+	ClearPriceOverride  bool `json:"clear_price_override"`
+	ClearCompareAtPrice bool `json:"clear_compare_at_price"`
+}
+
+// mergedWith fills the fields validated against each other from the
+// variant being updated, so the input is validated as the result will be.
+func (in VariantInput) mergedWith(current *Variant) VariantInput {
+	if in.PriceOverride == nil && !in.ClearPriceOverride {
+		in.PriceOverride = current.PriceOverride
+	}
+	if in.CompareAtPrice == nil && !in.ClearCompareAtPrice {
+		in.CompareAtPrice = current.CompareAtPrice
+	}
+	if in.FulfilmentMode == nil {
+		in.FulfilmentMode = &current.FulfilmentMode
+	}
+	if in.ExpectedShipDate == nil && current.ExpectedShipDate != nil {
+		date := current.ExpectedShipDate.Format(dateLayout)
+		in.ExpectedShipDate = &date
+	}
+	return in
+}
+
+// validPrice accepts positive amounts in whole cents. Most decimal prices
+// such as 19.99 have no exact float64, so cents are compared with a
+// tolerance.
+func validPrice(p float64) bool {
+	cents := p * 100
+	return p > 0 && p <= maxPrice && math.Abs(cents-math.Round(cents)) < 1e-6
+}
+
+func validImageURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validateProduct checks the product fields; creating requires name,
+// base_price and image.
+func validateProduct(in ProductInput, creating bool) []FieldError {
+	var errs []FieldError
+
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			errs = append(errs, FieldError{"name", "is required"})
+		} else if len(name) > maxNameLength {
+			errs = append(errs, FieldError{"name", "must be at most 200 characters"})
+		}
+	} else if creating {
+		errs = append(errs, FieldError{"name", "is required"})
+	}
+
+	if in.Description != nil && len(*in.Description) > maxDescriptionLength {
+		errs = append(errs, FieldError{"description", "must be at most 5000 characters"})
+	}
+
+	if in.BasePrice != nil {
+		if !validPrice(*in.BasePrice) {
+			errs = append(errs, FieldError{"base_price", "must be a positive amount with at most two decimals"})
+		}
+	} else if creating {
+		errs = append(errs, FieldError{"base_price", "is required"})
+	}
+
+	if in.Image != nil {
+		if !validImageURL(*in.Image) {
+			errs = append(errs, FieldError{"image", "must be an http(s) URL"})
+		}
+	} else if creating {
+		errs = append(errs, FieldError{"image", "is required"})
+	}
+
+	return errs
+}
+
+// validateVariant checks the variant fields against the price the variant
+// would sell at. prefix qualifies field names for nested variants.
+func validateVariant(in VariantInput, creating bool, basePrice float64, prefix string) []FieldError {
+	errs := validateSKU(in.SKU, prefix)
+
+	if in.StockQuantity != nil {
+		if *in.StockQuantity < 0 {
+			errs = append(errs, FieldError{prefix + "stock_quantity", "must not be negative"})
+		}
+	} else if creating {
+		errs = append(errs, FieldError{prefix + "stock_quantity", "is required"})
+	}
+
+	if in.ReorderThreshold != nil && *in.ReorderThreshold < 0 {
+		errs = append(errs, FieldError{prefix + "reorder_threshold", "must not be negative"})
+	}
+
+	if in.FulfilmentMode != nil {
+		switch *in.FulfilmentMode {
+		case stock.ModeInStock, stock.ModeBackorder:
+		case stock.ModePreorder:
+			if in.ExpectedShipDate == nil {
+				errs = append(errs, FieldError{prefix + "expected_ship_date", "is required for preorders"})
+			}
+		default:
+			errs = append(errs, FieldError{prefix + "fulfilment_mode", "must be in_stock, preorder or backorder"})
+		}
+	}
+	if in.ExpectedShipDate != nil {
+		if _, err := time.Parse(dateLayout, *in.ExpectedShipDate); err != nil {
+			errs = append(errs, FieldError{prefix + "expected_ship_date", "must be a date like 2025-01-31"})
+		}
+	}
+	if in.BackorderLimit != nil && *in.BackorderLimit < 0 {
+		errs = append(errs, FieldError{prefix + "backorder_limit", "must not be negative"})
+	}
+
+	if in.Image != nil && !validImageURL(*in.Image) {
+		errs = append(errs, FieldError{prefix + "image", "must be an http(s) URL"})
+	}
+
+	if in.ClearPriceOverride && in.PriceOverride != nil {
+		errs = append(errs, FieldError{prefix + "clear_price_override", "cannot be combined with price_override"})
+	}
+	if in.ClearCompareAtPrice && in.CompareAtPrice != nil {
+		errs = append(errs, FieldError{prefix + "clear_compare_at_price", "cannot be combined with compare_at_price"})
+	}
+
+	price := basePrice
+	if in.PriceOverride != nil {
+		if !validPrice(*in.PriceOverride) {
+			errs = append(errs, FieldError{prefix + "price_override", "must be a positive amount with at most two decimals"})
+		}
+		price = *in.PriceOverride
+	}
+	if in.CompareAtPrice != nil {
+		if !validPrice(*in.CompareAtPrice) {
+			errs = append(errs, FieldError{prefix + "compare_at_price", "must be a positive amount with at most two decimals"})
+		} else if *in.CompareAtPrice <= price {
+			errs = append(errs, FieldError{prefix + "compare_at_price", "must be higher than the selling price"})
+		}
+	}
+
+	return errs
+}
+
+// variantKey identifies a size and color combination, ignoring case.
+func variantKey(size, color string) string {
+	return strings.ToLower(strings.TrimSpace(size)) + "\x00" + strings.ToLower(strings.TrimSpace(color))
+}
+
+func writeValidationErrors(w http.ResponseWriter, errs []FieldError) {
+	writeFieldErrors(w, http.StatusUnprocessableEntity, errs)
+}
+
+// writeFieldErrors reports field errors with another status, e.g. 409 for
+// valid input the current stock cannot take.
+func writeFieldErrors(w http.ResponseWriter, status int, errs []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": errs})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import "testing"
+
+func TestValidPrice(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  bool
+	}{
+		{19.99, true},
+		{9.95, true},
+		{0.29, true},
+		{1.15, true},
+		{4.35, true},
+		{0.57, true},
+		{0.01, true},
+		{100, true},
+		{maxPrice, true},
+		{0, false},
+		{-5, false},
+		{1.001, false},
+		{19.995, false},
+		{maxPrice + 0.01, false},
+	}
+	for _, tt := range tests {
+		if got := validPrice(tt.price); got != tt.want {
+			t.Errorf("validPrice(%v) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestValidateVariantClearPriceOverride(t *testing.T) {
+	override := 80.0
+	compareAt := 90.0
+	current := &Variant{FulfilmentMode: "in_stock", PriceOverride: &override, CompareAtPrice: &compareAt}
+
+	// Back at the base price of 100, the old compare_at_price is too low.
+	in := VariantInput{ClearPriceOverride: true}
+	errs := validateVariant(in.mergedWith(current), false, 100, "")
+	if len(errs) != 1 || errs[0].Field != "compare_at_price" {
+		t.Errorf("clearing the override under a lower compare_at_price: got %v", errs)
+	}
+
+	in = VariantInput{ClearPriceOverride: true, ClearCompareAtPrice: true}
+	if errs := validateVariant(in.mergedWith(current), false, 100, ""); len(errs) != 0 {
+		t.Errorf("clearing both: got %v", errs)
+	}
+
+	in = VariantInput{ClearPriceOverride: true, PriceOverride: &override}
+	errs = validateVariant(in.mergedWith(current), false, 100, "")
+	if len(errs) == 0 || errs[0].Field != "clear_price_override" {
+		t.Errorf("clear combined with a new override: got %v", errs)
+	}
+}
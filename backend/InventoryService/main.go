@@ -1,11 +1,100 @@
 package main
 
 import (
+	"log"
+	"net/http"
+	"time"
+
+	"auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+
+	"InventoryService/db"
+	"InventoryService/events"
+	"InventoryService/handlers"
 	"InventoryService/rabbitmq"
+	"InventoryService/stock"
 )
 
 func main() {
-	go rabbitmq.StartInventoryConsumer()
+	db.Init()
+	events.Init()
+	rabbitmq.Connect()
+
+	rabbitmq.StartInventoryConsumer()
+	go stock.StartReservationSweeper(time.Minute, rabbitmq.PublishHoldExpired)
+	go stock.StartReconciler(time.Hour)
+	go stock.StartDigestScheduler(time.Hour, rabbitmq.PublishLowStockDigest)
+	go stock.StartRestockNotifier(time.Minute, rabbitmq.PublishBackInStock)
+	go stock.StartBackorderNotifier(time.Minute, rabbitmq.PublishBackorderFulfilled)
+
+	r := chi.NewRouter()
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	}))
+
+	// Webhook for the Hasura event triggers on products and product_variants
+	r.Post("/events/inventory", handlers.InventoryEventHandler)
+
+	// Seller product management
+	r.Route("/seller/products", func(r chi.Router) {
+		r.Use(auth.RequireRole("seller"))
+		r.Get("/", handlers.ListSellerProducts)
+		r.Post("/", handlers.CreateProduct)
+		r.Post("/import", handlers.ImportCatalog)
+		r.Get("/export", handlers.ExportCatalog)
+		r.Get("/{id}", handlers.GetSellerProduct)
+		r.Put("/{id}", handlers.UpdateProduct)
+		r.Post("/{id}/list", handlers.SetProductListed(true))
+		r.Post("/{id}/unlist", handlers.SetProductListed(false))
+		r.Post("/{id}/variants", handlers.CreateVariant)
+		r.Put("/{id}/variants/{variantID}", handlers.UpdateVariant)
+	})
+	r.With(auth.RequireRole("seller")).Get("/seller/sku-template", handlers.GetSKUTemplate)
+	r.With(auth.RequireRole("seller")).Put("/seller/sku-template", handlers.SetSKUTemplate)
+
+	// Stock ledger
+	r.With(auth.RequireRole("seller")).Get("/seller/stock/movements", handlers.ListStockMovements)
+	r.With(auth.RequireRole("seller")).Get("/seller/stock/low", handlers.ListLowStock)
+	r.With(auth.RequireRole("admin")).Get("/admin/stock/discrepancies", handlers.ListStockDiscrepancies)
+	r.With(auth.RequireRole("admin")).Get("/admin/stock/location-discrepancies", handlers.ListLocationDiscrepancies)
+
+	// Dead-lettered messages of any consumer queue on the broker
+	r.With(auth.RequireRole("admin")).Mount("/admin/dead-letters", rabbitmq.Client.DeadLetterHandler("/admin/dead-letters"))
+
+	// Seller locations and stock transfers between them
+	r.Route("/seller/locations", func(r chi.Router) {
+		r.Use(auth.RequireRole("seller"))
+		r.Get("/", handlers.ListLocations)
+		r.Post("/", handlers.CreateLocation)
+		r.Put("/{id}", handlers.UpdateLocation)
+		r.Get("/{id}/stock", handlers.GetLocationStock)
+		r.Put("/{id}/stock/{variantID}", handlers.SetLocationStock)
+	})
+	r.With(auth.RequireRole("seller")).Get("/seller/transfers", handlers.ListTransfers)
+	r.With(auth.RequireRole("seller")).Post("/seller/transfers", handlers.CreateTransfer)
+	r.With(auth.RequireRole("seller")).Get("/seller/allocations", handlers.ListAllocations)
+
+	// Checkout stock reservations
+	r.Route("/reservations", func(r chi.Router) {
+		r.Use(auth.RequireRole("buyer"))
+		r.Post("/", handlers.CreateReservation)
+		r.Get("/{id}", handlers.GetReservation)
+		r.Delete("/{id}", handlers.ReleaseReservation)
+	})
+
+	// Back-in-stock subscriptions
+	r.Route("/restock-subscriptions", func(r chi.Router) {
+		r.Use(auth.RequireRole("buyer"))
+		r.Get("/", handlers.ListRestockSubscriptions)
+		r.Post("/", handlers.Subscribe)
+		r.Delete("/{variantID}", handlers.Unsubscribe)
+	})
 
-	select {} // keep service running
+	log.Println("✅ InventoryService is running on port :8101")
+	log.Fatal(http.ListenAndServe(":8101", r))
 }
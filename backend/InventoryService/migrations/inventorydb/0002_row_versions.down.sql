@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS bump_product_variants_version ON product_variants;
+DROP TRIGGER IF EXISTS bump_products_version ON products;
+DROP FUNCTION IF EXISTS bump_row_version;
+
+ALTER TABLE product_variants DROP COLUMN IF EXISTS version;
+ALTER TABLE products DROP COLUMN IF EXISTS version;
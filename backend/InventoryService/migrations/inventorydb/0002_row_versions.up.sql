@@ -0,0 +1,23 @@
+-- Every product and variant row carries a version that is bumped on each
+-- update, so consumers of the change events can discard stale messages.
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE product_variants ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_row_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER bump_products_version
+BEFORE UPDATE ON products
+FOR EACH ROW
+EXECUTE FUNCTION bump_row_version();
+
+CREATE TRIGGER bump_product_variants_version
+BEFORE UPDATE ON product_variants
+FOR EACH ROW
+EXECUTE FUNCTION bump_row_version();
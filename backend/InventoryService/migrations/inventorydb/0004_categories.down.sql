@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS propagate_category_rename_after_update ON categories;
+DROP FUNCTION IF EXISTS propagate_category_rename;
+
+DROP TRIGGER IF EXISTS sync_product_category_before_write ON products;
+DROP FUNCTION IF EXISTS sync_product_category;
+
+DROP INDEX IF EXISTS idx_products_category_id;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+
+DROP FUNCTION IF EXISTS category_subtree;
+DROP TABLE IF EXISTS categories;
+DROP FUNCTION IF EXISTS category_slug;
@@ -0,0 +1,85 @@
+CREATE OR REPLACE FUNCTION category_slug(name TEXT)
+RETURNS TEXT AS $$
+    SELECT TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(TRIM(name)), '[^a-z0-9]+', '-', 'g'));
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    parent_id INTEGER REFERENCES categories (id) ON DELETE RESTRICT,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT categories_not_own_parent CHECK (parent_id <> id)
+);
+
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+
+-- Returns root_id and the IDs of all of its descendants.
+CREATE OR REPLACE FUNCTION category_subtree(root_id INTEGER)
+RETURNS SETOF INTEGER AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id FROM categories WHERE id = root_id
+        UNION ALL
+        SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+    )
+    SELECT id FROM tree;
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE products ADD COLUMN category_id INTEGER REFERENCES categories (id) ON DELETE SET NULL;
+CREATE INDEX idx_products_category_id ON products (category_id);
+
+-- Move the existing free-text categories onto the new table. Names that
+-- only differ in case or punctuation collapse into one category.
+INSERT INTO categories (name, slug)
+VALUES ('Uncategorized', 'uncategorized');
+
+INSERT INTO categories (name, slug)
+SELECT DISTINCT ON (category_slug(category)) TRIM(category), category_slug(category)
+FROM products
+WHERE category_slug(category) <> ''
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE products p
+SET category_id = c.id
+FROM categories c
+WHERE c.slug = COALESCE(NULLIF(category_slug(p.category), ''), 'uncategorized');
+
+-- products.category stays as a denormalised copy of the category name so
+-- existing Hasura queries keep working. Writers may set either column.
+CREATE OR REPLACE FUNCTION sync_product_category()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.category_id IS NOT NULL
+       AND (TG_OP = 'INSERT' OR NEW.category_id IS DISTINCT FROM OLD.category_id) THEN
+        SELECT name INTO NEW.category FROM categories WHERE id = NEW.category_id;
+    ELSIF TG_OP = 'INSERT' OR NEW.category IS DISTINCT FROM OLD.category THEN
+        SELECT id INTO NEW.category_id FROM categories WHERE slug = category_slug(NEW.category);
+        IF NEW.category_id IS NULL THEN
+            SELECT id INTO NEW.category_id FROM categories WHERE slug = 'uncategorized';
+            NEW.category := 'Uncategorized';
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER sync_product_category_before_write
+BEFORE INSERT OR UPDATE ON products
+FOR EACH ROW
+EXECUTE FUNCTION sync_product_category();
+
+CREATE OR REPLACE FUNCTION propagate_category_rename()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products SET category = NEW.name WHERE category_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER propagate_category_rename_after_update
+AFTER UPDATE OF name ON categories
+FOR EACH ROW
+WHEN (OLD.name IS DISTINCT FROM NEW.name)
+EXECUTE FUNCTION propagate_category_rename();
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS refresh_product_rating_after_write ON product_reviews;
+DROP FUNCTION IF EXISTS refresh_product_rating;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS rating_breakdown,
+    DROP COLUMN IF EXISTS rating_count,
+    DROP COLUMN IF EXISTS rating_average;
+
+DROP TABLE IF EXISTS product_reviews;
@@ -0,0 +1,57 @@
+CREATE TABLE product_reviews (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    order_id INTEGER NOT NULL,
+    buyer_id INTEGER NOT NULL,
+    buyer_username TEXT NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    photo_urls TEXT[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'hidden')),
+    moderation_note TEXT,
+    seller_reply TEXT,
+    seller_replied_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT product_reviews_one_per_buyer UNIQUE (product_id, buyer_id)
+);
+
+CREATE INDEX idx_product_reviews_product ON product_reviews (product_id, status, created_at DESC);
+
+-- Rating aggregates over published reviews, kept on the product row.
+ALTER TABLE products
+    ADD COLUMN rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN rating_breakdown JSONB NOT NULL DEFAULT '{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}';
+
+CREATE OR REPLACE FUNCTION refresh_product_rating()
+RETURNS TRIGGER AS $$
+DECLARE
+    target_id INTEGER := COALESCE(NEW.product_id, OLD.product_id);
+BEGIN
+    UPDATE products p
+    SET rating_average = COALESCE(agg.average, 0),
+        rating_count = COALESCE(agg.total, 0),
+        rating_breakdown = jsonb_build_object(
+            '1', COALESCE(agg.r1, 0), '2', COALESCE(agg.r2, 0), '3', COALESCE(agg.r3, 0),
+            '4', COALESCE(agg.r4, 0), '5', COALESCE(agg.r5, 0))
+    FROM (
+        SELECT ROUND(AVG(rating), 2) AS average,
+               COUNT(*) AS total,
+               COUNT(*) FILTER (WHERE rating = 1) AS r1,
+               COUNT(*) FILTER (WHERE rating = 2) AS r2,
+               COUNT(*) FILTER (WHERE rating = 3) AS r3,
+               COUNT(*) FILTER (WHERE rating = 4) AS r4,
+               COUNT(*) FILTER (WHERE rating = 5) AS r5
+        FROM product_reviews
+        WHERE product_id = target_id AND status = 'published'
+    ) agg
+    WHERE p.id = target_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER refresh_product_rating_after_write
+AFTER INSERT OR UPDATE OF rating, status OR DELETE ON product_reviews
+FOR EACH ROW
+EXECUTE FUNCTION refresh_product_rating();
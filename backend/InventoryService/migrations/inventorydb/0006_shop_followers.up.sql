@@ -0,0 +1,10 @@
+-- Buyers following a seller's shop. Shops live in authdb, so they are keyed
+-- by the seller's username like products.seller_username.
+CREATE TABLE shop_followers (
+    seller_username TEXT NOT NULL,
+    buyer_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (seller_username, buyer_id)
+);
+
+CREATE INDEX idx_shop_followers_buyer ON shop_followers (buyer_id);
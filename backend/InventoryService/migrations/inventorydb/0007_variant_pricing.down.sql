@@ -0,0 +1,3 @@
+ALTER TABLE product_variants
+    DROP COLUMN IF EXISTS compare_at_price,
+    DROP COLUMN IF EXISTS price_override;
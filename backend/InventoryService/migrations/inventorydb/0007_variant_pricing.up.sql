@@ -0,0 +1,5 @@
+-- Variant-level pricing. A NULL price_override sells the variant at the
+-- product's base_price; compare_at_price is the struck-through "was" price.
+ALTER TABLE product_variants
+    ADD COLUMN price_override NUMERIC CHECK (price_override > 0),
+    ADD COLUMN compare_at_price NUMERIC CHECK (compare_at_price > 0);
@@ -0,0 +1,13 @@
+-- Ledger of stock changes. The unique key makes each (reason, reference)
+-- apply at most once per variant, e.g. one decrement per order.
+CREATE TABLE stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    delta INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    reference_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT stock_movements_once UNIQUE (reason, reference_id, variant_id)
+);
+
+CREATE INDEX idx_stock_movements_variant ON stock_movements (variant_id, created_at);
@@ -0,0 +1 @@
+ALTER TABLE product_variants DROP CONSTRAINT IF EXISTS product_variants_stock_non_negative;
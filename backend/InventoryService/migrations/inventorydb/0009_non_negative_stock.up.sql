@@ -0,0 +1,6 @@
+-- Oversold variants from before the check are clamped to zero so the
+-- constraint can be added.
+UPDATE product_variants SET stock_quantity = 0 WHERE stock_quantity < 0;
+
+ALTER TABLE product_variants
+    ADD CONSTRAINT product_variants_stock_non_negative CHECK (stock_quantity >= 0);
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS variant_availability;
+DROP TABLE IF EXISTS stock_reservation_items;
+DROP TABLE IF EXISTS stock_reservations;
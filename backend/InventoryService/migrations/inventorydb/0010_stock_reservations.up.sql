@@ -0,0 +1,41 @@
+-- Stock held for a buyer between checkout and order confirmation. A
+-- reservation only holds stock while it is active and not past expires_at;
+-- the sweeper marks stale ones expired.
+CREATE TABLE stock_reservations (
+    id SERIAL PRIMARY KEY,
+    buyer_id INTEGER NOT NULL,
+    order_id INTEGER,
+    status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'committed', 'released', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_reservations_active ON stock_reservations (expires_at) WHERE status = 'active';
+CREATE INDEX idx_stock_reservations_order ON stock_reservations (order_id);
+
+CREATE TABLE stock_reservation_items (
+    reservation_id INTEGER NOT NULL REFERENCES stock_reservations (id) ON DELETE CASCADE,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, variant_id)
+);
+
+CREATE INDEX idx_stock_reservation_items_variant ON stock_reservation_items (variant_id);
+
+-- Available-to-sell is on-hand stock minus what active reservations hold.
+CREATE VIEW variant_availability AS
+SELECT v.id AS variant_id,
+       v.product_id,
+       v.stock_quantity AS on_hand,
+       COALESCE(held.quantity, 0)::INTEGER AS reserved,
+       GREATEST(v.stock_quantity - COALESCE(held.quantity, 0), 0)::INTEGER AS available_to_sell
+FROM product_variants v
+LEFT JOIN (
+    SELECT i.variant_id, SUM(i.quantity) AS quantity
+    FROM stock_reservation_items i
+    JOIN stock_reservations r ON r.id = i.reservation_id
+    WHERE r.status = 'active' AND r.expires_at > NOW()
+    GROUP BY i.variant_id
+) held ON held.variant_id = v.id;
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS stock_discrepancies;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+DROP FUNCTION IF EXISTS reject_stock_movement_change;
+
+DELETE FROM stock_movements WHERE reason <> 'sale' AND reason <> 'restock' AND reason <> 'return';
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_reason;
+
+UPDATE stock_movements SET reason = 'order' WHERE reason = 'sale';
+UPDATE stock_movements SET reason = 'refund' WHERE reason = 'restock' AND note = 'payment refunded';
+UPDATE stock_movements SET reason = 'order_cancelled' WHERE reason = 'restock';
+DELETE FROM stock_movements WHERE reference_id IS NULL;
+
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS note,
+    DROP COLUMN IF EXISTS actor,
+    ALTER COLUMN reference_id SET NOT NULL;
@@ -0,0 +1,52 @@
+-- stock_movements becomes the append-only ledger behind every stock change.
+-- Manual adjustments have no reference, and NULLs never collide in the
+-- unique key, so only referenced movements are deduplicated.
+ALTER TABLE stock_movements
+    ALTER COLUMN reference_id DROP NOT NULL,
+    ADD COLUMN actor TEXT NOT NULL DEFAULT 'system',
+    ADD COLUMN note TEXT;
+
+UPDATE stock_movements SET reason = 'sale' WHERE reason = 'order';
+UPDATE stock_movements SET reason = 'restock', note = 'order cancelled' WHERE reason = 'order_cancelled';
+UPDATE stock_movements SET reason = 'restock', note = 'payment refunded' WHERE reason = 'refund';
+
+ALTER TABLE stock_movements
+    ADD CONSTRAINT stock_movements_reason CHECK (
+        reason IN ('sale', 'restock', 'adjustment', 'return', 'import', 'opening_balance')
+    );
+
+-- Open the ledger with whatever stock is not yet explained by it, so that
+-- every variant's movements sum to its stock_quantity.
+INSERT INTO stock_movements (variant_id, delta, reason, reference_id, note)
+SELECT v.id, v.stock_quantity - COALESCE(SUM(m.delta), 0), 'opening_balance', 'opening', 'ledger backfill'
+FROM product_variants v
+LEFT JOIN stock_movements m ON m.variant_id = v.id
+GROUP BY v.id
+HAVING v.stock_quantity - COALESCE(SUM(m.delta), 0) <> 0;
+
+CREATE OR REPLACE FUNCTION reject_stock_movement_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Deleting a variant cascades to its movements; that is the only way
+    -- rows may leave the ledger.
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only
+BEFORE UPDATE OR DELETE ON stock_movements
+FOR EACH ROW
+EXECUTE FUNCTION reject_stock_movement_change();
+
+-- Variants whose stock_quantity disagrees with their ledger, refreshed by
+-- the reconciliation job.
+CREATE TABLE stock_discrepancies (
+    variant_id INTEGER PRIMARY KEY REFERENCES product_variants (id) ON DELETE CASCADE,
+    stock_quantity INTEGER NOT NULL,
+    ledger_total INTEGER NOT NULL,
+    first_detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_checked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS stock_alert_digests;
+DROP TABLE IF EXISTS stock_alerts;
+
+ALTER TABLE product_variants DROP COLUMN IF EXISTS reorder_threshold;
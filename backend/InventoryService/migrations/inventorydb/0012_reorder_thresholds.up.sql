@@ -0,0 +1,30 @@
+-- Sellers pick, per variant, the stock level at which they want to reorder.
+-- 0 means they only want to hear when the variant sells out.
+ALTER TABLE product_variants
+    ADD COLUMN reorder_threshold INTEGER NOT NULL DEFAULT 0 CHECK (reorder_threshold >= 0);
+
+-- Alerts raised when a decrement takes a variant to or below its threshold.
+-- version is the variant row version after the decrement, so a redelivered
+-- Hasura event does not raise the same alert twice.
+CREATE TABLE stock_alerts (
+    id SERIAL PRIMARY KEY,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    seller_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('low_stock', 'out_of_stock')),
+    stock_quantity INTEGER NOT NULL,
+    reorder_threshold INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (variant_id, version)
+);
+
+CREATE INDEX stock_alerts_seller_idx ON stock_alerts (seller_id, created_at);
+
+-- One low-stock digest per seller per day.
+CREATE TABLE stock_alert_digests (
+    seller_id INTEGER NOT NULL,
+    digest_date DATE NOT NULL,
+    item_count INTEGER NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (seller_id, digest_date)
+);
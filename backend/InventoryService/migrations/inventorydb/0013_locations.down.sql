@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS stock_transfers;
+DROP TABLE IF EXISTS stock_allocations;
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS location_stock;
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,68 @@
+-- Places a seller ships from. Every seller has exactly one default
+-- location, which receives stock that is not assigned anywhere else.
+CREATE TABLE locations (
+    id SERIAL PRIMARY KEY,
+    seller_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    province TEXT,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (seller_id, name)
+);
+
+CREATE UNIQUE INDEX locations_one_default ON locations (seller_id) WHERE is_default;
+
+-- Stock per variant per location. product_variants.stock_quantity stays
+-- the total across locations, so readers of the variant need not change.
+CREATE TABLE location_stock (
+    location_id INTEGER NOT NULL REFERENCES locations (id),
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (location_id, variant_id)
+);
+
+CREATE INDEX location_stock_variant_idx ON location_stock (variant_id);
+
+-- The location a movement happened at, when it concerns a single one.
+-- Sales split across locations leave it NULL; see stock_allocations.
+ALTER TABLE stock_movements ADD COLUMN location_id INTEGER REFERENCES locations (id);
+
+-- Where each order line ships from.
+CREATE TABLE stock_allocations (
+    order_id INTEGER NOT NULL,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations (id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, variant_id, location_id)
+);
+
+-- Stock moved between two locations of the same seller. Transfers leave
+-- the variant total unchanged, so they are not stock movements.
+CREATE TABLE stock_transfers (
+    id SERIAL PRIMARY KEY,
+    seller_id INTEGER NOT NULL,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    from_location_id INTEGER NOT NULL REFERENCES locations (id),
+    to_location_id INTEGER NOT NULL REFERENCES locations (id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    actor TEXT NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (from_location_id <> to_location_id)
+);
+
+CREATE INDEX stock_transfers_seller_idx ON stock_transfers (seller_id, created_at);
+
+-- Existing stock all sits at each seller's default location.
+INSERT INTO locations (seller_id, name, is_default)
+SELECT DISTINCT seller_id, 'Main warehouse', TRUE FROM products;
+
+INSERT INTO location_stock (location_id, variant_id, quantity)
+SELECT l.id, v.id, v.stock_quantity
+FROM product_variants v
+JOIN products p ON p.id = v.product_id
+JOIN locations l ON l.seller_id = p.seller_id AND l.is_default;
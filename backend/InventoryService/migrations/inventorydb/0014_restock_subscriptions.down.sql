@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS restock_waves;
+DROP TABLE IF EXISTS restock_subscriptions;
@@ -0,0 +1,31 @@
+-- Buyers waiting for a sold-out variant. A buyer has at most one pending
+-- subscription per variant; notified ones are kept as history.
+CREATE TABLE restock_subscriptions (
+    id SERIAL PRIMARY KEY,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    buyer_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX restock_subscriptions_pending
+    ON restock_subscriptions (variant_id, buyer_id) WHERE notified_at IS NULL;
+CREATE INDEX restock_subscriptions_queue
+    ON restock_subscriptions (variant_id, created_at) WHERE notified_at IS NULL;
+
+-- One wave per restock of a sold-out variant. The notifier works through
+-- the pending subscribers of an open wave in batches, oldest first, and
+-- closes it when they are all notified or the variant sells out again.
+-- version is the variant row version of the restock, so a redelivered
+-- Hasura event does not open a second wave.
+CREATE TABLE restock_waves (
+    id SERIAL PRIMARY KEY,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    notified INTEGER NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP,
+    UNIQUE (variant_id, version)
+);
+
+CREATE INDEX restock_waves_open ON restock_waves (opened_at) WHERE closed_at IS NULL;
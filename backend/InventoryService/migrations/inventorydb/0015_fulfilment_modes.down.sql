@@ -0,0 +1,27 @@
+DROP TABLE IF EXISTS stock_backorders;
+
+CREATE OR REPLACE VIEW variant_availability AS
+SELECT v.id AS variant_id,
+       v.product_id,
+       v.stock_quantity AS on_hand,
+       COALESCE(held.quantity, 0)::INTEGER AS reserved,
+       GREATEST(v.stock_quantity - COALESCE(held.quantity, 0), 0)::INTEGER AS available_to_sell
+FROM product_variants v
+LEFT JOIN (
+    SELECT i.variant_id, SUM(i.quantity) AS quantity
+    FROM stock_reservation_items i
+    JOIN stock_reservations r ON r.id = i.reservation_id
+    WHERE r.status = 'active' AND r.expires_at > NOW()
+    GROUP BY i.variant_id
+) held ON held.variant_id = v.id;
+
+ALTER TABLE product_variants DROP CONSTRAINT product_variants_stock_within_limit;
+UPDATE product_variants SET stock_quantity = 0 WHERE stock_quantity < 0;
+ALTER TABLE product_variants
+    ADD CONSTRAINT product_variants_stock_non_negative CHECK (stock_quantity >= 0);
+
+ALTER TABLE product_variants
+    DROP CONSTRAINT IF EXISTS product_variants_preorder_ship_date,
+    DROP COLUMN IF EXISTS backorder_limit,
+    DROP COLUMN IF EXISTS expected_ship_date,
+    DROP COLUMN IF EXISTS fulfilment_mode;
@@ -0,0 +1,61 @@
+-- How a variant is sold when it has no stock on hand:
+--   in_stock  only from stock on hand (the default),
+--   preorder  before its first stock arrives, shipping around
+--             expected_ship_date,
+--   backorder while waiting for a restock.
+-- Preorders and backorders let stock_quantity go negative, by at most
+-- backorder_limit units.
+ALTER TABLE product_variants
+    ADD COLUMN fulfilment_mode TEXT NOT NULL DEFAULT 'in_stock'
+        CHECK (fulfilment_mode IN ('in_stock', 'preorder', 'backorder')),
+    ADD COLUMN expected_ship_date DATE,
+    ADD COLUMN backorder_limit INTEGER NOT NULL DEFAULT 0 CHECK (backorder_limit >= 0),
+    ADD CONSTRAINT product_variants_preorder_ship_date
+        CHECK (fulfilment_mode <> 'preorder' OR expected_ship_date IS NOT NULL);
+
+ALTER TABLE product_variants DROP CONSTRAINT product_variants_stock_non_negative;
+ALTER TABLE product_variants
+    ADD CONSTRAINT product_variants_stock_within_limit CHECK (
+        stock_quantity >= 0 OR (fulfilment_mode <> 'in_stock' AND stock_quantity >= -backorder_limit)
+    );
+
+-- Preorders and backorders can be bought up to the limit.
+CREATE OR REPLACE VIEW variant_availability AS
+SELECT v.id AS variant_id,
+       v.product_id,
+       v.stock_quantity AS on_hand,
+       COALESCE(held.quantity, 0)::INTEGER AS reserved,
+       GREATEST(
+           v.stock_quantity - COALESCE(held.quantity, 0)
+           + CASE WHEN v.fulfilment_mode = 'in_stock' THEN 0 ELSE v.backorder_limit END,
+           0
+       )::INTEGER AS available_to_sell
+FROM product_variants v
+LEFT JOIN (
+    SELECT i.variant_id, SUM(i.quantity) AS quantity
+    FROM stock_reservation_items i
+    JOIN stock_reservations r ON r.id = i.reservation_id
+    WHERE r.status = 'active' AND r.expires_at > NOW()
+    GROUP BY i.variant_id
+) held ON held.variant_id = v.id;
+
+-- Order lines sold without stock on hand, waiting for it to arrive. Stock
+-- received at a location goes to the oldest open lines first; location
+-- stock therefore adds up to stock_quantity plus the open quantity.
+CREATE TABLE stock_backorders (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    fulfilment_mode TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    open_quantity INTEGER NOT NULL CHECK (open_quantity >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    fulfilled_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    -- Set once OrderService has been told the line can ship.
+    notified_at TIMESTAMP,
+    UNIQUE (order_id, variant_id)
+);
+
+CREATE INDEX stock_backorders_open ON stock_backorders (variant_id, created_at) WHERE open_quantity > 0;
+CREATE INDEX stock_backorders_unnotified ON stock_backorders (fulfilled_at) WHERE fulfilled_at IS NOT NULL AND notified_at IS NULL;
@@ -0,0 +1,35 @@
+DROP TABLE IF EXISTS sku_sequences;
+DROP TABLE IF EXISTS seller_sku_settings;
+
+DROP TRIGGER IF EXISTS default_variant_sku ON product_variants;
+DROP FUNCTION IF EXISTS default_variant_sku();
+
+CREATE OR REPLACE FUNCTION generate_sku()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_sku TEXT;
+BEGIN
+    new_sku := CONCAT(
+        'SKU-',
+        NEW.product_id, '-',
+        COALESCE(NEW.size, 'X'), '-',
+        COALESCE(NEW.color, 'X'), '-',
+        FLOOR(RANDOM() * 100000)::INT
+    );
+    NEW.sku := new_sku;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_sku_before_insert
+BEFORE INSERT ON product_variants
+FOR EACH ROW
+EXECUTE FUNCTION generate_sku();
+
+-- Fails if two sellers now share a SKU; rename those first.
+DROP INDEX IF EXISTS product_variants_seller_sku;
+ALTER TABLE product_variants ADD CONSTRAINT product_variants_sku_key UNIQUE (sku);
+
+DROP TRIGGER IF EXISTS set_variant_seller ON product_variants;
+DROP FUNCTION IF EXISTS set_variant_seller();
+ALTER TABLE product_variants DROP COLUMN IF EXISTS seller_id;
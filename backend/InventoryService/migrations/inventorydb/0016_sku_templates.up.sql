@@ -0,0 +1,61 @@
+-- Variant SKUs are generated by InventoryService from a per-seller template
+-- or set by the seller, and only have to be unique within the seller's
+-- catalog. seller_id is copied onto variants so the database can enforce
+-- that.
+ALTER TABLE product_variants ADD COLUMN seller_id INTEGER;
+
+UPDATE product_variants v SET seller_id = p.seller_id
+FROM products p WHERE p.id = v.product_id;
+
+ALTER TABLE product_variants ALTER COLUMN seller_id SET NOT NULL;
+
+CREATE OR REPLACE FUNCTION set_variant_seller()
+RETURNS TRIGGER AS $$
+BEGIN
+    SELECT seller_id INTO NEW.seller_id FROM products WHERE id = NEW.product_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_variant_seller
+BEFORE INSERT OR UPDATE OF product_id ON product_variants
+FOR EACH ROW
+EXECUTE FUNCTION set_variant_seller();
+
+ALTER TABLE product_variants DROP CONSTRAINT product_variants_sku_key;
+CREATE UNIQUE INDEX product_variants_seller_sku ON product_variants (seller_id, LOWER(sku));
+
+-- generate_sku appended a random number, which could collide and fail the
+-- insert. Variants inserted outside InventoryService (e.g. from the Hasura
+-- console) without a SKU now get one derived from their ID instead.
+DROP TRIGGER IF EXISTS set_sku_before_insert ON product_variants;
+DROP FUNCTION IF EXISTS generate_sku();
+
+CREATE OR REPLACE FUNCTION default_variant_sku()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.sku IS NULL OR NEW.sku = '' THEN
+        NEW.sku := CONCAT('SKU-', NEW.product_id, '-', NEW.id);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER default_variant_sku
+BEFORE INSERT ON product_variants
+FOR EACH ROW
+EXECUTE FUNCTION default_variant_sku();
+
+-- The template a seller's variant SKUs are generated from; sellers without
+-- a row use the default in InventoryService.
+CREATE TABLE seller_sku_settings (
+    seller_id INTEGER PRIMARY KEY,
+    template TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The last {seq} value handed out per seller.
+CREATE TABLE sku_sequences (
+    seller_id INTEGER PRIMARY KEY,
+    last_value INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS products_base_price_below_compare_at ON products;
+DROP FUNCTION IF EXISTS check_base_price_below_compare_at();
+DROP TRIGGER IF EXISTS product_variants_compare_at_price ON product_variants;
+DROP FUNCTION IF EXISTS check_variant_compare_at_price();
+ALTER TABLE product_variants DROP CONSTRAINT IF EXISTS product_variants_compare_at_above_price;
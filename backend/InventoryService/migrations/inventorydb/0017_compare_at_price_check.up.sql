@@ -0,0 +1,53 @@
+-- compare_at_price is the struck-through "was" price, so it must stay above
+-- the price the variant sells at: price_override, or the product's
+-- base_price without one. NOT VALID leaves existing rows alone but checks
+-- every new write.
+ALTER TABLE product_variants
+    ADD CONSTRAINT product_variants_compare_at_above_price CHECK (
+        compare_at_price IS NULL OR price_override IS NULL OR compare_at_price > price_override
+    ) NOT VALID;
+
+-- The base_price half spans two tables and is checked by triggers that
+-- raise check_violation under the name of the constraint they enforce.
+CREATE OR REPLACE FUNCTION check_variant_compare_at_price()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.compare_at_price IS NOT NULL AND NEW.price_override IS NULL
+       AND NEW.compare_at_price <= (SELECT base_price FROM products WHERE id = NEW.product_id) THEN
+        RAISE EXCEPTION 'compare_at_price must be higher than the base price'
+            USING ERRCODE = 'check_violation',
+                  CONSTRAINT = 'product_variants_compare_at_above_price',
+                  TABLE = 'product_variants';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER product_variants_compare_at_price
+BEFORE INSERT OR UPDATE OF compare_at_price, price_override, product_id ON product_variants
+FOR EACH ROW
+EXECUTE FUNCTION check_variant_compare_at_price();
+
+CREATE OR REPLACE FUNCTION check_base_price_below_compare_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM product_variants
+        WHERE product_id = NEW.id
+          AND price_override IS NULL
+          AND compare_at_price <= NEW.base_price
+    ) THEN
+        RAISE EXCEPTION 'base_price must be lower than the compare_at_price of variants without a price override'
+            USING ERRCODE = 'check_violation',
+                  CONSTRAINT = 'products_base_price_below_compare_at',
+                  TABLE = 'products';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_base_price_below_compare_at
+BEFORE UPDATE OF base_price ON products
+FOR EACH ROW
+WHEN (NEW.base_price IS DISTINCT FROM OLD.base_price)
+EXECUTE FUNCTION check_base_price_below_compare_at();
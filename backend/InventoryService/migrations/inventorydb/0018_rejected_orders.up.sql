@@ -0,0 +1,8 @@
+-- Orders rejected for insufficient stock, with the shortages reported. A
+-- redelivered or replayed order.placed is rejected again from here rather
+-- than taking stock that arrived since.
+CREATE TABLE rejected_orders (
+    order_id INTEGER PRIMARY KEY,
+    shortages JSONB NOT NULL,
+    rejected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_stock_reservations_expiry_unnotified;
+DROP INDEX IF EXISTS idx_stock_reservations_order;
+CREATE INDEX idx_stock_reservations_order ON stock_reservations (order_id);
+
+ALTER TABLE stock_reservations
+    DROP COLUMN IF EXISTS expiry_notified_at,
+    DROP COLUMN IF EXISTS shipping_province;
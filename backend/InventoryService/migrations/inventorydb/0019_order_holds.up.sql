@@ -0,0 +1,12 @@
+-- A placed order holds its stock as a reservation linked to the order
+-- until it is paid or confirmed; holds of orders left unpaid expire like
+-- checkout reservations.
+ALTER TABLE stock_reservations
+    ADD COLUMN shipping_province TEXT,
+    -- Set once OrderService has been told an order's hold expired.
+    ADD COLUMN expiry_notified_at TIMESTAMP;
+
+DROP INDEX idx_stock_reservations_order;
+CREATE UNIQUE INDEX idx_stock_reservations_order ON stock_reservations (order_id);
+CREATE INDEX idx_stock_reservations_expiry_unnotified ON stock_reservations (updated_at)
+    WHERE status = 'expired' AND order_id IS NOT NULL AND expiry_notified_at IS NULL;
@@ -0,0 +1,12 @@
+-- Order lines the seller's locations could not cover although the
+-- variant's total could, i.e. location stock had drifted from
+-- stock_quantity. The missing units were allocated to the default
+-- location; its stock should be counted.
+CREATE TABLE location_discrepancies (
+    order_id INTEGER NOT NULL,
+    variant_id INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    location_id INTEGER NOT NULL REFERENCES locations (id),
+    missing INTEGER NOT NULL CHECK (missing > 0),
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, variant_id)
+);
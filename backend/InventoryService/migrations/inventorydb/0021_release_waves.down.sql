@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS restock_waves_one_open;
+DELETE FROM restock_waves WHERE version IS NULL;
+ALTER TABLE restock_waves ALTER COLUMN version SET NOT NULL;
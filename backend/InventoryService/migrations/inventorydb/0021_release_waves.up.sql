@@ -0,0 +1,13 @@
+-- Stock freed by expired or released reservations also opens a wave, if
+-- buyers subscribed while everything on hand was held. Such waves have no
+-- variant row version.
+ALTER TABLE restock_waves ALTER COLUMN version DROP NOT NULL;
+
+-- At most one open wave per variant.
+UPDATE restock_waves w SET closed_at = NOW()
+WHERE closed_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM restock_waves o
+      WHERE o.variant_id = w.variant_id AND o.closed_at IS NULL AND o.id < w.id
+  );
+CREATE UNIQUE INDEX restock_waves_one_open ON restock_waves (variant_id) WHERE closed_at IS NULL;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS cancelled_orders;
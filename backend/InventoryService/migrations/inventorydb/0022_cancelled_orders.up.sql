@@ -0,0 +1,7 @@
+-- Orders whose order.cancelled was handled. order.placed and
+-- order.confirmed arrive on other queues and may be retried after the
+-- cancellation; they must not hold or take stock for the order then.
+CREATE TABLE cancelled_orders (
+    order_id INTEGER PRIMARY KEY,
+    cancelled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,68 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"messaging/contracts"
+
+	"InventoryService/stock"
+)
+
+// StockRejectedMessage tells OrderService that an order could not be
+// fulfilled and no stock was taken for it.
+type StockRejectedMessage struct {
+	OrderID int              `json:"order_id"`
+	Reason  string           `json:"reason"`
+	Items   []stock.Shortage `json:"items"`
+}
+
+// OrderBackorderedMessage tells OrderService which lines of an order were
+// sold as preorders or backorders and must not ship yet.
+type OrderBackorderedMessage struct {
+	OrderID int               `json:"order_id"`
+	Items   []stock.Backorder `json:"items"`
+}
+
+// Reasons of a stock.rejected.
+const (
+	RejectedInsufficientStock = "insufficient_stock"
+	RejectedHoldExpired       = "hold_expired"
+)
+
+func PublishStockRejected(ctx context.Context, message StockRejectedMessage) error {
+	return Client.PublishEvent(ctx, contracts.StockRejected, message)
+}
+
+// PublishHoldExpired tells OrderService an order was not paid before its
+// stock hold expired.
+func PublishHoldExpired(hold stock.ExpiredHold) error {
+	return PublishStockRejected(context.Background(), StockRejectedMessage{
+		OrderID: hold.OrderID,
+		Reason:  RejectedHoldExpired,
+		Items:   []stock.Shortage{},
+	})
+}
+
+// PublishStockAlert tells sellers a variant went low or sold out.
+func PublishStockAlert(alert stock.Alert) error {
+	return Client.PublishEvent(context.Background(), contracts.StockAlert, alert)
+}
+
+// PublishLowStockDigest sends a seller's daily list of low-stock variants.
+func PublishLowStockDigest(digest stock.Digest) error {
+	return Client.PublishEvent(context.Background(), contracts.StockLowStockDigest, digest)
+}
+
+// PublishBackInStock sends one batch of back-in-stock notifications.
+func PublishBackInStock(event stock.BackInStock) error {
+	return Client.PublishEvent(context.Background(), contracts.StockBackInStock, event)
+}
+
+func PublishOrderBackordered(ctx context.Context, message OrderBackorderedMessage) error {
+	return Client.PublishEvent(ctx, contracts.StockBackordered, message)
+}
+
+// PublishBackorderFulfilled tells OrderService a held-back line can ship.
+func PublishBackorderFulfilled(message stock.BackorderFulfilled) error {
+	return Client.PublishEvent(context.Background(), contracts.StockBackorderFulfilled, message)
+}
@@ -0,0 +1,210 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+)
+
+// Alert kinds raised when a decrement crosses a variant's reorder
+// threshold.
+const (
+	AlertLowStock   = "low_stock"
+	AlertOutOfStock = "out_of_stock"
+)
+
+// velocityWindow is how far back sales are counted when ranking low-stock
+// variants by how fast they sell.
+const velocityWindow = 30
+
+type Alert struct {
+	ID               int       `json:"id"`
+	Kind             string    `json:"kind"`
+	VariantID        int       `json:"variant_id"`
+	ProductID        int       `json:"product_id"`
+	SellerID         int       `json:"seller_id"`
+	SKU              string    `json:"sku"`
+	StockQuantity    int       `json:"stock_quantity"`
+	ReorderThreshold int       `json:"reorder_threshold"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ThresholdCrossed returns the alert kind for a stock change from previous
+// to current, or "" if the change did not cross the threshold downwards.
+// Stock that was already at or below the threshold does not alert again
+// until it has been restocked above it.
+func ThresholdCrossed(previous, current, threshold int) string {
+	switch {
+	case current >= previous:
+		return ""
+	case current <= 0 && previous > 0:
+		return AlertOutOfStock
+	case current <= threshold && previous > threshold:
+		return AlertLowStock
+	}
+	return ""
+}
+
+// RecordAlert stores an alert for the variant at the given row version. It
+// returns false if that version already raised one.
+func RecordAlert(ctx context.Context, kind string, variantID, version, quantity, threshold int) (*Alert, bool, error) {
+	a := Alert{Kind: kind, VariantID: variantID, StockQuantity: quantity, ReorderThreshold: threshold}
+	err := db.Pool.QueryRow(ctx, `
+		WITH variant AS (
+			SELECT v.id, v.product_id, v.sku, p.seller_id
+			FROM product_variants v
+			JOIN products p ON p.id = v.product_id
+			WHERE v.id = $1
+		), inserted AS (
+			INSERT INTO stock_alerts (variant_id, version, seller_id, kind, stock_quantity, reorder_threshold)
+			SELECT id, $2, seller_id, $3, $4, $5 FROM variant
+			ON CONFLICT (variant_id, version) DO NOTHING
+			RETURNING id, created_at
+		)
+		SELECT inserted.id, inserted.created_at, variant.product_id, variant.sku, variant.seller_id
+		FROM inserted, variant`,
+		variantID, version, kind, quantity, threshold).Scan(&a.ID, &a.CreatedAt, &a.ProductID, &a.SKU, &a.SellerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &a, true, nil
+}
+
+// LowStockItem is a variant at or below its reorder threshold.
+// DailySales is the average over the last 30 days; DaysOfCover is how long
+// the remaining stock lasts at that rate, nil if the variant did not sell.
+type LowStockItem struct {
+	VariantID        int      `json:"variant_id"`
+	ProductID        int      `json:"product_id"`
+	ProductName      string   `json:"product_name"`
+	SKU              string   `json:"sku"`
+	Size             string   `json:"size"`
+	Color            string   `json:"color"`
+	StockQuantity    int      `json:"stock_quantity"`
+	ReorderThreshold int      `json:"reorder_threshold"`
+	UnitsSold        int      `json:"units_sold_30d"`
+	DailySales       float64  `json:"daily_sales"`
+	DaysOfCover      *float64 `json:"days_of_cover"`
+}
+
+// LowStock lists the seller's variants at or below their reorder
+// threshold, fastest selling first.
+func LowStock(ctx context.Context, sellerID int) ([]LowStockItem, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT v.id, p.id, p.name, v.sku, COALESCE(v.size, ''), COALESCE(v.color, ''),
+		       v.stock_quantity, v.reorder_threshold, COALESCE(s.units, 0)::INTEGER
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN (
+			SELECT variant_id, -SUM(delta) AS units
+			FROM stock_movements
+			WHERE reason = $2 AND created_at > NOW() - make_interval(days => $3)
+			GROUP BY variant_id
+		) s ON s.variant_id = v.id
+		WHERE p.seller_id = $1 AND v.stock_quantity <= v.reorder_threshold
+		ORDER BY COALESCE(s.units, 0) DESC, v.stock_quantity, v.id`,
+		sellerID, ReasonSale, velocityWindow)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []LowStockItem{}
+	for rows.Next() {
+		var item LowStockItem
+		if err := rows.Scan(&item.VariantID, &item.ProductID, &item.ProductName, &item.SKU, &item.Size, &item.Color,
+			&item.StockQuantity, &item.ReorderThreshold, &item.UnitsSold); err != nil {
+			return nil, err
+		}
+		item.DailySales = float64(item.UnitsSold) / velocityWindow
+		if item.DailySales > 0 {
+			cover := float64(item.StockQuantity) / item.DailySales
+			item.DaysOfCover = &cover
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Digest is one seller's daily summary of low-stock variants.
+type Digest struct {
+	SellerID int            `json:"seller_id"`
+	Date     string         `json:"date"`
+	Items    []LowStockItem `json:"items"`
+}
+
+// SendDigests hands publish a digest for every seller with low-stock
+// variants who has not had one today (UTC). A seller whose digest fails to
+// publish is retried on the next run.
+func SendDigests(ctx context.Context, publish func(Digest) error) error {
+	today := time.Now().UTC().Format("2006-01-02")
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT p.seller_id
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.stock_quantity <= v.reorder_threshold
+		  AND NOT EXISTS (
+			SELECT 1 FROM stock_alert_digests d
+			WHERE d.seller_id = p.seller_id AND d.digest_date = $1::date
+		  )`, today)
+	if err != nil {
+		return err
+	}
+	var sellerIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		sellerIDs = append(sellerIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, sellerID := range sellerIDs {
+		items, err := LowStock(ctx, sellerID)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			continue
+		}
+		if err := publish(Digest{SellerID: sellerID, Date: today, Items: items}); err != nil {
+			log.Printf("❌ Failed to publish low-stock digest for seller %d: %v", sellerID, err)
+			continue
+		}
+		_, err = db.Pool.Exec(ctx, `
+			INSERT INTO stock_alert_digests (seller_id, digest_date, item_count)
+			VALUES ($1, $2::date, $3)
+			ON CONFLICT DO NOTHING`, sellerID, today, len(items))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartDigestScheduler runs SendDigests every interval; each seller gets at
+// most one digest a day however often it runs.
+func StartDigestScheduler(interval time.Duration, publish func(Digest) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := SendDigests(context.Background(), publish); err != nil {
+			log.Printf("❌ Low-stock digest run failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+)
+
+// Fulfilment modes of a variant.
+const (
+	ModeInStock   = "in_stock"
+	ModePreorder  = "preorder"
+	ModeBackorder = "backorder"
+)
+
+// Backorder is the part of an order line sold without stock on hand.
+type Backorder struct {
+	VariantID        int     `json:"variant_id"`
+	Quantity         int     `json:"quantity"`
+	FulfilmentMode   string  `json:"fulfilment_mode"`
+	ExpectedShipDate *string `json:"expected_ship_date,omitempty"` // YYYY-MM-DD
+}
+
+// BackorderFulfilled tells OrderService that stock has arrived for a line
+// it was holding back.
+type BackorderFulfilled struct {
+	OrderID   int `json:"order_id"`
+	VariantID int `json:"variant_id"`
+}
+
+// openBackorder records the part of an order line that has to wait for
+// stock.
+func openBackorder(ctx context.Context, tx pgx.Tx, orderID int, b Backorder) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO stock_backorders (order_id, variant_id, fulfilment_mode, quantity, open_quantity)
+		VALUES ($1, $2, $3, $4, $4)`, orderID, b.VariantID, b.FulfilmentMode, b.Quantity)
+	return err
+}
+
+// receive adds quantity of a variant to a location and hands as much of it
+// as needed to the oldest open backorders, allocating them to the location.
+func receive(ctx context.Context, tx pgx.Tx, locationID, variantID, quantity int) error {
+	if err := adjustLocation(ctx, tx, locationID, variantID, quantity); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, order_id, open_quantity FROM stock_backorders
+		WHERE variant_id = $1 AND open_quantity > 0
+		ORDER BY created_at, id
+		FOR UPDATE`, variantID)
+	if err != nil {
+		return err
+	}
+	type open struct{ id, orderID, quantity int }
+	var backorders []open
+	for rows.Next() {
+		var b open
+		if err := rows.Scan(&b.id, &b.orderID, &b.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		backorders = append(backorders, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range backorders {
+		if quantity == 0 {
+			break
+		}
+		take := b.quantity
+		if take > quantity {
+			take = quantity
+		}
+		if err := adjustLocation(ctx, tx, locationID, variantID, -take); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO stock_allocations (order_id, variant_id, location_id, quantity)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (order_id, variant_id, location_id)
+			DO UPDATE SET quantity = stock_allocations.quantity + EXCLUDED.quantity`,
+			b.orderID, variantID, locationID, take)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE stock_backorders SET
+				open_quantity = open_quantity - $2,
+				fulfilled_at = CASE WHEN open_quantity = $2 THEN NOW() END
+			WHERE id = $1`, b.id, take)
+		if err != nil {
+			return err
+		}
+		quantity -= take
+	}
+	return nil
+}
+
+// cancelBackorder drops up to quantity still open on an order line and
+// returns how much was dropped; that part never took stock from a
+// location.
+func cancelBackorder(ctx context.Context, tx pgx.Tx, orderID, variantID, quantity int) (int, error) {
+	var cancelled int
+	err := tx.QueryRow(ctx, `
+		WITH line AS (
+			SELECT id, LEAST(open_quantity, $3) AS quantity FROM stock_backorders
+			WHERE order_id = $1 AND variant_id = $2
+			FOR UPDATE
+		)
+		UPDATE stock_backorders b SET
+			open_quantity = b.open_quantity - line.quantity,
+			cancelled_at = CASE WHEN b.open_quantity = line.quantity THEN NOW() END
+		FROM line
+		WHERE b.id = line.id
+		RETURNING line.quantity`, orderID, variantID, quantity).Scan(&cancelled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return cancelled, err
+}
+
+// NotifyFulfilledBackorders hands every backorder line that has received
+// all its stock to publish, marking it once publish succeeds.
+func NotifyFulfilledBackorders(ctx context.Context, publish func(BackorderFulfilled) error) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, order_id, variant_id FROM stock_backorders
+		WHERE fulfilled_at IS NOT NULL AND notified_at IS NULL
+		ORDER BY fulfilled_at, id`)
+	if err != nil {
+		return err
+	}
+	type line struct {
+		id      int
+		message BackorderFulfilled
+	}
+	var lines []line
+	for rows.Next() {
+		var l line
+		if err := rows.Scan(&l.id, &l.message.OrderID, &l.message.VariantID); err != nil {
+			rows.Close()
+			return err
+		}
+		lines = append(lines, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		if err := publish(l.message); err != nil {
+			return err
+		}
+		_, err := db.Pool.Exec(ctx, `
+			UPDATE stock_backorders SET notified_at = NOW() WHERE id = $1`, l.id)
+		if err != nil {
+			return err
+		}
+		log.Printf("📦 Order %d: backordered variant %d can ship", l.message.OrderID, l.message.VariantID)
+	}
+	return nil
+}
+
+// StartBackorderNotifier runs NotifyFulfilledBackorders every interval.
+func StartBackorderNotifier(interval time.Duration, publish func(BackorderFulfilled) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := NotifyFulfilledBackorders(context.Background(), publish); err != nil {
+			log.Printf("❌ Backorder notification run failed: %v", err)
+		}
+	}
+}
+
+// OrderBackorders lists the lines of an order that were sold without stock
+// on hand and are still waiting for it.
+func OrderBackorders(ctx context.Context, orderID int) ([]Backorder, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT b.variant_id, b.quantity, b.fulfilment_mode, v.expected_ship_date::text
+		FROM stock_backorders b
+		JOIN product_variants v ON v.id = b.variant_id
+		WHERE b.order_id = $1 AND b.open_quantity > 0
+		ORDER BY b.variant_id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var backorders []Backorder
+	for rows.Next() {
+		var b Backorder
+		if err := rows.Scan(&b.VariantID, &b.Quantity, &b.FulfilmentMode, &b.ExpectedShipDate); err != nil {
+			return nil, err
+		}
+		backorders = append(backorders, b)
+	}
+	return backorders, rows.Err()
+}
@@ -0,0 +1,393 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+)
+
+// Allocation strategies, chosen with the ALLOCATION_STRATEGY env var.
+const (
+	// StrategyMostStock ships from the location holding the most stock.
+	StrategyMostStock = "most_stock"
+	// StrategyNearest prefers locations in the buyer's province and falls
+	// back to the most stock.
+	StrategyNearest = "nearest"
+)
+
+// defaultLocationName is given to the location created for a seller who has
+// none yet.
+const defaultLocationName = "Main warehouse"
+
+var (
+	ErrLocationNotFound = errors.New("location not found")
+	ErrLocationInUse    = errors.New("location still holds stock")
+	ErrLocationShort    = errors.New("not enough stock at location")
+)
+
+type Location struct {
+	ID         int       `json:"id"`
+	SellerID   int       `json:"seller_id"`
+	Name       string    `json:"name"`
+	Province   string    `json:"province"`
+	IsDefault  bool      `json:"is_default"`
+	Active     bool      `json:"active"`
+	TotalStock int       `json:"total_stock"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Allocation is the part of an order line shipped from one location.
+type Allocation struct {
+	OrderID    int `json:"order_id"`
+	VariantID  int `json:"variant_id"`
+	LocationID int `json:"location_id"`
+	Quantity   int `json:"quantity"`
+}
+
+type Transfer struct {
+	ID             int       `json:"id"`
+	VariantID      int       `json:"variant_id"`
+	FromLocationID int       `json:"from_location_id"`
+	ToLocationID   int       `json:"to_location_id"`
+	Quantity       int       `json:"quantity"`
+	Actor          string    `json:"actor"`
+	Note           *string   `json:"note"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func allocationStrategy() string {
+	if os.Getenv("ALLOCATION_STRATEGY") == StrategyNearest {
+		return StrategyNearest
+	}
+	return StrategyMostStock
+}
+
+// DefaultLocation returns the seller's default location, creating it if
+// the seller has none.
+func DefaultLocation(ctx context.Context, tx pgx.Tx, sellerID int) (int, error) {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO locations (seller_id, name, is_default)
+		SELECT $1, $2, TRUE
+		WHERE NOT EXISTS (SELECT 1 FROM locations WHERE seller_id = $1 AND is_default)
+		ON CONFLICT DO NOTHING`, sellerID, defaultLocationName)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM locations WHERE seller_id = $1 AND is_default`, sellerID).Scan(&id)
+	return id, err
+}
+
+// variantDefaultLocation is DefaultLocation for the seller owning variantID.
+func variantDefaultLocation(ctx context.Context, tx pgx.Tx, variantID int) (int, error) {
+	var sellerID int
+	err := tx.QueryRow(ctx, `
+		SELECT p.seller_id FROM product_variants v JOIN products p ON p.id = v.product_id
+		WHERE v.id = $1`, variantID).Scan(&sellerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrUnknownVariant
+	}
+	if err != nil {
+		return 0, err
+	}
+	return DefaultLocation(ctx, tx, sellerID)
+}
+
+// adjustLocation applies delta to the variant's stock at a location,
+// returning errShort if that would take it below zero.
+func adjustLocation(ctx context.Context, tx pgx.Tx, locationID, variantID, delta int) error {
+	if delta >= 0 {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO location_stock (location_id, variant_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (location_id, variant_id)
+			DO UPDATE SET quantity = location_stock.quantity + EXCLUDED.quantity, updated_at = NOW()`,
+			locationID, variantID, delta)
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE location_stock SET quantity = quantity + $3, updated_at = NOW()
+		WHERE location_id = $1 AND variant_id = $2 AND quantity + $3 >= 0`,
+		locationID, variantID, delta)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errShort
+	}
+	return nil
+}
+
+type locationStock struct {
+	locationID int
+	province   string
+	isDefault  bool
+	quantity   int
+}
+
+// stockedLocations lists the active locations holding the variant, most
+// stock first, locking their rows.
+func stockedLocations(ctx context.Context, tx pgx.Tx, variantID int) ([]locationStock, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT ls.location_id, COALESCE(l.province, ''), l.is_default, ls.quantity
+		FROM location_stock ls
+		JOIN locations l ON l.id = ls.location_id
+		WHERE ls.variant_id = $1 AND ls.quantity > 0 AND l.active
+		ORDER BY ls.quantity DESC, l.is_default DESC, l.id
+		FOR UPDATE OF ls`, variantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocked []locationStock
+	for rows.Next() {
+		var s locationStock
+		if err := rows.Scan(&s.locationID, &s.province, &s.isDefault, &s.quantity); err != nil {
+			return nil, err
+		}
+		stocked = append(stocked, s)
+	}
+	return stocked, rows.Err()
+}
+
+// takeFromLocations removes quantity of the variant from the given
+// locations, emptying them in order until the quantity is covered. It
+// returns the part the locations could not cover.
+func takeFromLocations(ctx context.Context, tx pgx.Tx, variantID, quantity int, stocked []locationStock) ([]Allocation, int, error) {
+	var allocations []Allocation
+	for _, s := range stocked {
+		if quantity == 0 {
+			break
+		}
+		take := s.quantity
+		if take > quantity {
+			take = quantity
+		}
+		if err := adjustLocation(ctx, tx, s.locationID, variantID, -take); err != nil {
+			return nil, 0, err
+		}
+		allocations = append(allocations, Allocation{VariantID: variantID, LocationID: s.locationID, Quantity: take})
+		quantity -= take
+	}
+	return allocations, quantity, nil
+}
+
+// allocate picks the locations an order line ships from using the
+// configured strategy and records them against the order. A single
+// location that holds the whole quantity is preferred over splitting the
+// line into several parcels.
+//
+// The variant's total already covers the line, so if its locations do not
+// they have drifted from it: the rest is allocated to the default location
+// and recorded in location_discrepancies rather than failing the order.
+func allocate(ctx context.Context, tx pgx.Tx, orderID, variantID, quantity int, province string) error {
+	stocked, err := stockedLocations(ctx, tx, variantID)
+	if err != nil {
+		return err
+	}
+	if allocationStrategy() == StrategyNearest && province != "" {
+		sort.SliceStable(stocked, func(i, j int) bool {
+			return strings.EqualFold(stocked[i].province, province) && !strings.EqualFold(stocked[j].province, province)
+		})
+	}
+	for _, s := range stocked {
+		if s.quantity >= quantity {
+			stocked = []locationStock{s}
+			break
+		}
+	}
+
+	allocations, missing, err := takeFromLocations(ctx, tx, variantID, quantity, stocked)
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		locationID, err := variantDefaultLocation(ctx, tx, variantID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO location_discrepancies (order_id, variant_id, location_id, missing)
+			VALUES ($1, $2, $3, $4)`, orderID, variantID, locationID, missing)
+		if err != nil {
+			return err
+		}
+		log.Printf("⚠️ Order %d: locations are %d short of variant %d, allocated to default location %d", orderID, missing, variantID, locationID)
+		allocations = addAllocation(allocations, Allocation{VariantID: variantID, LocationID: locationID, Quantity: missing})
+	}
+
+	for _, a := range allocations {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO stock_allocations (order_id, variant_id, location_id, quantity)
+			VALUES ($1, $2, $3, $4)`, orderID, a.VariantID, a.LocationID, a.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addAllocation adds a to the allocation of the same location, if there is
+// one.
+func addAllocation(allocations []Allocation, a Allocation) []Allocation {
+	for i := range allocations {
+		if allocations[i].LocationID == a.LocationID {
+			allocations[i].Quantity += a.Quantity
+			return allocations
+		}
+	}
+	return append(allocations, a)
+}
+
+// reduceLocations takes a manual decrement of the variant total from the
+// default location first, then from the fullest.
+func reduceLocations(ctx context.Context, tx pgx.Tx, variantID, quantity int) error {
+	stocked, err := stockedLocations(ctx, tx, variantID)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(stocked, func(i, j int) bool {
+		return stocked[i].isDefault && !stocked[j].isDefault
+	})
+	_, missing, err := takeFromLocations(ctx, tx, variantID, quantity, stocked)
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		// The locations no longer add up to the variant's total.
+		return ErrLocationShort
+	}
+	return nil
+}
+
+// restockLocation is where stock coming back from an order goes: the
+// location that shipped most of the variant, or the default one.
+func restockLocation(ctx context.Context, tx pgx.Tx, orderID, variantID int) (int, error) {
+	var locationID int
+	err := tx.QueryRow(ctx, `
+		SELECT a.location_id FROM stock_allocations a
+		JOIN locations l ON l.id = a.location_id
+		WHERE a.order_id = $1 AND a.variant_id = $2 AND l.active
+		ORDER BY a.quantity DESC, a.location_id
+		LIMIT 1`, orderID, variantID).Scan(&locationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return variantDefaultLocation(ctx, tx, variantID)
+	}
+	return locationID, err
+}
+
+// SetLocationQuantity brings a variant's stock at one of the seller's
+// locations to quantity, recording the difference in the ledger.
+func SetLocationQuantity(ctx context.Context, sellerID, locationID, variantID, quantity int, reason, actor string) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockVariants(ctx, tx, []int{variantID}); err != nil {
+		return err
+	}
+	if err := checkOwnership(ctx, tx, sellerID, variantID, locationID); err != nil {
+		return err
+	}
+
+	var current int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE((SELECT quantity FROM location_stock WHERE location_id = $1 AND variant_id = $2), 0)`,
+		locationID, variantID).Scan(&current)
+	if err != nil {
+		return err
+	}
+	if quantity != current {
+		_, err = record(ctx, tx, Movement{
+			VariantID:  variantID,
+			LocationID: locationID,
+			Delta:      quantity - current,
+			Reason:     reason,
+			Actor:      actor,
+		}, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// TransferStock moves quantity of a variant between two of the seller's
+// locations.
+func TransferStock(ctx context.Context, sellerID int, t Transfer) (*Transfer, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockVariants(ctx, tx, []int{t.VariantID}); err != nil {
+		return nil, err
+	}
+	if err := checkOwnership(ctx, tx, sellerID, t.VariantID, t.FromLocationID, t.ToLocationID); err != nil {
+		return nil, err
+	}
+
+	err = adjustLocation(ctx, tx, t.FromLocationID, t.VariantID, -t.Quantity)
+	if errors.Is(err, errShort) {
+		return nil, ErrLocationShort
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := adjustLocation(ctx, tx, t.ToLocationID, t.VariantID, t.Quantity); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO stock_transfers (seller_id, variant_id, from_location_id, to_location_id, quantity, actor, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		sellerID, t.VariantID, t.FromLocationID, t.ToLocationID, t.Quantity, t.Actor, t.Note).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, tx.Commit(ctx)
+}
+
+// checkOwnership verifies the variant and every location belong to the
+// seller and the locations are active.
+func checkOwnership(ctx context.Context, tx pgx.Tx, sellerID, variantID int, locationIDs ...int) error {
+	var owner int
+	err := tx.QueryRow(ctx, `
+		SELECT p.seller_id FROM product_variants v JOIN products p ON p.id = v.product_id
+		WHERE v.id = $1`, variantID).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && owner != sellerID) {
+		return ErrUnknownVariant
+	}
+	if err != nil {
+		return err
+	}
+
+	var found int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM locations WHERE id = ANY($1) AND seller_id = $2 AND active`,
+		locationIDs, sellerID).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found != len(locationIDs) {
+		return ErrLocationNotFound
+	}
+	return nil
+}
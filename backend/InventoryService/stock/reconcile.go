@@ -0,0 +1,69 @@
+package stock
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"InventoryService/db"
+)
+
+// Reconcile compares every variant's stock_quantity with the sum of its
+// ledger and refreshes stock_discrepancies: drifting variants are flagged
+// (keeping when they were first seen) and variants back in line are
+// cleared. It returns the number of variants currently flagged.
+func Reconcile(ctx context.Context) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		CREATE TEMP TABLE drift ON COMMIT DROP AS
+		SELECT v.id AS variant_id, v.stock_quantity, COALESCE(SUM(m.delta), 0)::INTEGER AS ledger_total
+		FROM product_variants v
+		LEFT JOIN stock_movements m ON m.variant_id = v.id
+		GROUP BY v.id
+		HAVING v.stock_quantity <> COALESCE(SUM(m.delta), 0)`)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM stock_discrepancies
+		WHERE variant_id NOT IN (SELECT variant_id FROM drift)`)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO stock_discrepancies (variant_id, stock_quantity, ledger_total)
+		SELECT variant_id, stock_quantity, ledger_total FROM drift
+		ON CONFLICT (variant_id) DO UPDATE SET
+			stock_quantity = EXCLUDED.stock_quantity,
+			ledger_total = EXCLUDED.ledger_total,
+			last_checked_at = NOW()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), tx.Commit(ctx)
+}
+
+// StartReconciler runs Reconcile every interval.
+func StartReconciler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		flagged, err := Reconcile(context.Background())
+		if err != nil {
+			log.Printf("❌ Stock reconciliation failed: %v", err)
+			continue
+		}
+		if flagged > 0 {
+			log.Printf("⚠️ %d variant(s) have stock that does not match the ledger", flagged)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+	"InventoryService/events"
+)
+
+// DefaultReservationTTL is how long checkout holds stock for an order that
+// has not been placed yet.
+const DefaultReservationTTL = 15 * time.Minute
+
+// UnpaidOrderTTL is how long a placed order holds its stock while it waits
+// to be paid.
+const UnpaidOrderTTL = 30 * time.Minute
+
+// A buyer's active checkout reservations are capped, so one account cannot
+// keep an item's stock off sale by reserving it over and over.
+const (
+	MaxActiveReservations = 3
+	MaxReservedUnits      = 50
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationClosed   = errors.New("reservation is no longer active")
+	ErrReservationLimit    = errors.New("too many active reservations")
+)
+
+type Reservation struct {
+	ID        int         `json:"id"`
+	BuyerID   int         `json:"buyer_id"`
+	OrderID   *int        `json:"order_id"`
+	Status    string      `json:"status"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Items     []OrderItem `json:"items"`
+}
+
+// Reserve holds the items for buyerID until ttl passes. Either every item
+// is held or, with an *InsufficientStockError, none is. It returns
+// ErrReservationLimit if the buyer already has MaxActiveReservations
+// active reservations or the items would take them past MaxReservedUnits.
+func Reserve(ctx context.Context, buyerID int, items []OrderItem, ttl time.Duration) (*Reservation, error) {
+	totals, variantIDs := aggregate(items)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkReservationLimit(ctx, tx, buyerID, items); err != nil {
+		return nil, err
+	}
+	if err := lockVariants(ctx, tx, variantIDs); err != nil {
+		return nil, err
+	}
+
+	var shortages []Shortage
+	for _, variantID := range variantIDs {
+		var available int
+		err := tx.QueryRow(ctx, `
+			SELECT available_to_sell FROM variant_availability WHERE variant_id = $1`,
+			variantID).Scan(&available)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUnknownVariant
+		}
+		if err != nil {
+			return nil, err
+		}
+		if available < totals[variantID] {
+			shortages = append(shortages, Shortage{VariantID: variantID, Requested: totals[variantID], Available: available})
+		}
+	}
+	if len(shortages) > 0 {
+		return nil, &InsufficientStockError{Shortages: shortages}
+	}
+
+	r := &Reservation{BuyerID: buyerID, Status: "active"}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO stock_reservations (buyer_id, expires_at)
+		VALUES ($1, NOW() + make_interval(secs => $2))
+		RETURNING id, expires_at`, buyerID, ttl.Seconds()).Scan(&r.ID, &r.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := reserveItems(ctx, tx, r.ID, totals, variantIDs); err != nil {
+		return nil, err
+	}
+	for _, variantID := range variantIDs {
+		r.Items = append(r.Items, OrderItem{VariantID: variantID, Quantity: totals[variantID]})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	publishAvailability(ctx, variantIDs)
+	return r, nil
+}
+
+// checkReservationLimit returns ErrReservationLimit if buyerID may not
+// reserve items on top of their active checkout reservations. It holds a
+// lock on the buyer until tx ends, so concurrent requests cannot both fit
+// under the limit.
+func checkReservationLimit(ctx context.Context, tx pgx.Tx, buyerID int, items []OrderItem) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, buyerLockClass, buyerID); err != nil {
+		return err
+	}
+
+	var reservations, units int
+	err := tx.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT r.id), COALESCE(SUM(i.quantity), 0)
+		FROM stock_reservations r
+		LEFT JOIN stock_reservation_items i ON i.reservation_id = r.id
+		WHERE r.buyer_id = $1 AND r.order_id IS NULL
+		  AND r.status = 'active' AND r.expires_at > NOW()`, buyerID).Scan(&reservations, &units)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		units += item.Quantity
+	}
+	if reservations >= MaxActiveReservations || units > MaxReservedUnits {
+		return ErrReservationLimit
+	}
+	return nil
+}
+
+func reserveItems(ctx context.Context, tx pgx.Tx, reservationID int, totals map[int]int, variantIDs []int) error {
+	for _, variantID := range variantIDs {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO stock_reservation_items (reservation_id, variant_id, quantity)
+			VALUES ($1, $2, $3)`, reservationID, variantID, totals[variantID])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reservationItems(ctx context.Context, tx pgx.Tx, reservationID int) ([]OrderItem, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT variant_id, quantity FROM stock_reservation_items
+		WHERE reservation_id = $1 ORDER BY variant_id`, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []OrderItem
+	for rows.Next() {
+		var item OrderItem
+		if err := rows.Scan(&item.VariantID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetReservation returns a reservation owned by buyerID.
+func GetReservation(ctx context.Context, id, buyerID int) (*Reservation, error) {
+	r := &Reservation{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, buyer_id, order_id,
+		       CASE WHEN status = 'active' AND expires_at <= NOW() THEN 'expired' ELSE status END,
+		       expires_at
+		FROM stock_reservations
+		WHERE id = $1 AND buyer_id = $2`, id, buyerID).Scan(&r.ID, &r.BuyerID, &r.OrderID, &r.Status, &r.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT variant_id, quantity FROM stock_reservation_items
+		WHERE reservation_id = $1 ORDER BY variant_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item OrderItem
+		if err := rows.Scan(&item.VariantID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		r.Items = append(r.Items, item)
+	}
+	return r, rows.Err()
+}
+
+// Release gives back the stock held by an active reservation of buyerID.
+// Once an order holds it, only the order's cancellation or expiry does.
+func Release(ctx context.Context, id, buyerID int) error {
+	var exists bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE id = $1 AND buyer_id = $2)`,
+		id, buyerID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrReservationNotFound
+	}
+
+	variantIDs, err := closeReservations(ctx, `id = $1 AND status = 'active' AND order_id IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if len(variantIDs) == 0 {
+		return ErrReservationClosed
+	}
+	publishAvailability(ctx, variantIDs)
+	if err := openReleasedWaves(ctx, variantIDs); err != nil {
+		log.Printf("❌ Failed to open restock waves: %v", err)
+	}
+	return nil
+}
+
+// ExpireReservations marks active reservations past their TTL as expired.
+// They stop holding stock as soon as they expire; this only tidies their
+// status, tells ProductService about the freed stock and opens restock
+// waves for buyers waiting for it.
+func ExpireReservations(ctx context.Context) error {
+	variantIDs, err := closeReservations(ctx, `status = 'active' AND expires_at <= NOW()`)
+	if err != nil {
+		return err
+	}
+	if len(variantIDs) > 0 {
+		log.Printf("⌛ Expired reservations on %d variant(s)", len(variantIDs))
+		publishAvailability(ctx, variantIDs)
+		if err := openReleasedWaves(ctx, variantIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeReservations moves the reservations matching where to released (or
+// expired, once past their TTL) and returns the variants they held.
+func closeReservations(ctx context.Context, where string, args ...interface{}) ([]int, error) {
+	rows, err := db.Pool.Query(ctx, `
+		WITH closed AS (
+			UPDATE stock_reservations
+			SET status = CASE WHEN expires_at <= NOW() THEN 'expired' ELSE 'released' END,
+			    updated_at = NOW()
+			WHERE `+where+`
+			RETURNING id
+		)
+		SELECT DISTINCT i.variant_id
+		FROM stock_reservation_items i
+		JOIN closed ON closed.id = i.reservation_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variantIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		variantIDs = append(variantIDs, id)
+	}
+	return variantIDs, rows.Err()
+}
+
+// releaseHold releases the hold of a cancelled order if it is still
+// active and returns the variants it held. A hold past its TTL is released
+// too, so it is not reported as expired.
+func releaseHold(ctx context.Context, tx pgx.Tx, orderID int) ([]int, error) {
+	rows, err := tx.Query(ctx, `
+		WITH released AS (
+			UPDATE stock_reservations SET status = 'released', updated_at = NOW()
+			WHERE order_id = $1 AND status = 'active'
+			RETURNING id
+		)
+		SELECT DISTINCT i.variant_id
+		FROM stock_reservation_items i
+		JOIN released ON released.id = i.reservation_id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variantIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		variantIDs = append(variantIDs, id)
+	}
+	return variantIDs, rows.Err()
+}
+
+// ExpiredHold is an order that was not paid before its stock hold expired.
+type ExpiredHold struct {
+	OrderID int
+}
+
+// NotifyExpiredHolds hands every order whose hold expired to publish, so
+// OrderService can cancel it, and marks each one once it is published.
+// Orders cancelled meanwhile are left out.
+func NotifyExpiredHolds(ctx context.Context, publish func(ExpiredHold) error) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, order_id FROM stock_reservations
+		WHERE status = 'expired' AND order_id IS NOT NULL AND expiry_notified_at IS NULL
+		  AND order_id NOT IN (SELECT order_id FROM cancelled_orders)
+		ORDER BY updated_at`)
+	if err != nil {
+		return err
+	}
+	type hold struct {
+		id    int
+		order ExpiredHold
+	}
+	var holds []hold
+	for rows.Next() {
+		var h hold
+		if err := rows.Scan(&h.id, &h.order.OrderID); err != nil {
+			rows.Close()
+			return err
+		}
+		holds = append(holds, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, h := range holds {
+		if err := publish(h.order); err != nil {
+			return err
+		}
+		_, err := db.Pool.Exec(ctx, `
+			UPDATE stock_reservations SET expiry_notified_at = NOW() WHERE id = $1`, h.id)
+		if err != nil {
+			return err
+		}
+		log.Printf("⌛ Order %d was not paid in time, its stock hold expired", h.order.OrderID)
+	}
+	return nil
+}
+
+// StartReservationSweeper expires stale reservations every interval and
+// reports the orders whose holds expired.
+func StartReservationSweeper(interval time.Duration, publish func(ExpiredHold) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		if err := ExpireReservations(ctx); err != nil {
+			log.Printf("❌ Failed to expire reservations: %v", err)
+		}
+		if err := NotifyExpiredHolds(ctx, publish); err != nil {
+			log.Printf("❌ Failed to report expired order holds: %v", err)
+		}
+	}
+}
+
+// publishAvailability tells ProductService the new available-to-sell of
+// each variant. Failures are only logged; the value is also recomputed on
+// every read.
+func publishAvailability(ctx context.Context, variantIDs []int) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT variant_id, product_id, available_to_sell
+		FROM variant_availability WHERE variant_id = ANY($1)`, variantIDs)
+	if err != nil {
+		log.Printf("❌ Failed to load availability: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event := events.InventoryEvent{Type: events.TypeAvailabilityChanged, Available: new(int)}
+		if err := rows.Scan(&event.VariantID, &event.ProductID, event.Available); err != nil {
+			log.Printf("❌ Failed to load availability: %v", err)
+			return
+		}
+		if err := events.Publish(events.ChannelProductUpdated, event); err != nil {
+			log.Printf("❌ Failed to publish %s: %v", event.Type, err)
+		}
+	}
+}
@@ -0,0 +1,721 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+)
+
+// Movement reasons recorded in stock_movements. Sales and restocks of an
+// order use the order ID as reference, returns "<order ID>/<return ID>".
+const (
+	ReasonSale       = "sale"
+	ReasonRestock    = "restock"
+	ReasonAdjustment = "adjustment"
+	ReasonReturn     = "return"
+	ReasonImport     = "import"
+)
+
+// ActorSystem marks movements made by InventoryService itself, e.g. when
+// consuming order events.
+const ActorSystem = "system"
+
+// SellerActor identifies a seller making a manual change.
+func SellerActor(sellerID int) string {
+	return fmt.Sprintf("seller:%d", sellerID)
+}
+
+// Movement is one ledger entry. Reference is empty for manual changes,
+// which are never deduplicated. LocationID is zero when the movement is
+// spread over several locations.
+type Movement struct {
+	VariantID  int
+	LocationID int
+	Delta      int
+	Reason     string
+	Reference  string
+	Actor      string
+	Note       string
+}
+
+var restockReasons = []string{ReasonRestock, ReasonReturn}
+
+// ErrUnknownVariant is returned when an order references a variant that
+// does not exist; retrying will not help.
+var ErrUnknownVariant = errors.New("unknown variant")
+
+// ErrInvalidQuantity is returned when an order line is not for at least
+// one unit; a negative quantity would otherwise add stock.
+var ErrInvalidQuantity = errors.New("quantity must be positive")
+
+// ErrNoHold is returned when an order is confirmed before its stock was
+// held, i.e. before its order.placed was handled; retrying helps.
+var ErrNoHold = errors.New("order holds no stock yet")
+
+// ErrHoldExpired is returned when an order is confirmed after its hold
+// expired; its stock went back on sale and is not taken.
+var ErrHoldExpired = errors.New("order's stock hold expired")
+
+// ErrOrderCancelled is returned when an order's order.placed or
+// order.confirmed is handled after its cancellation; no stock is held or
+// taken for it.
+var ErrOrderCancelled = errors.New("order was cancelled")
+
+type OrderItem struct {
+	VariantID int `json:"variant_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// Shortage is a variant an order wanted more of than was in stock.
+type Shortage struct {
+	VariantID int `json:"variant_id"`
+	Requested int `json:"requested"`
+	Available int `json:"available"`
+}
+
+// InsufficientStockError rejects a whole order; no stock was changed.
+type InsufficientStockError struct {
+	Shortages []Shortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %d variant(s)", len(e.Shortages))
+}
+
+// errShort is returned by record when a decrement would take stock below
+// zero.
+var errShort = errors.New("stock would go negative")
+
+// HoldOrder holds the stock of a newly placed order until ApplyOrder takes
+// it once the order is confirmed, or the hold expires after
+// UnpaidOrderTTL. A still-valid checkout reservation of the same buyer
+// becomes the hold, so the stock it held counts towards the order; stock
+// held for other buyers does not. It returns false without changing
+// anything if the order already has a hold, ErrOrderCancelled if the
+// order was cancelled before, and an *InsufficientStockError listing every
+// short variant if any item cannot be held. A rejection is recorded, so
+// the same order is rejected again if its event is redelivered.
+func HoldOrder(ctx context.Context, orderID, buyerID, reservationID int, province string, items []OrderItem) (bool, error) {
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return false, fmt.Errorf("%w: variant %d has quantity %d", ErrInvalidQuantity, item.VariantID, item.Quantity)
+		}
+	}
+	totals, variantIDs := aggregate(items)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOrder(ctx, tx, orderID); err != nil {
+		return false, err
+	}
+	if err := checkCancelled(ctx, tx, orderID); err != nil {
+		return false, err
+	}
+	rejected, err := rejection(ctx, tx, orderID)
+	if err != nil {
+		return false, err
+	}
+	if rejected != nil {
+		return false, rejected
+	}
+	var held bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE order_id = $1)`, orderID).Scan(&held)
+	if err != nil {
+		return false, err
+	}
+	if held {
+		return false, nil
+	}
+	if err := lockVariants(ctx, tx, variantIDs); err != nil {
+		return false, err
+	}
+
+	// The hold goes in a savepoint, so a rejection can undo it and still
+	// be recorded.
+	work, err := tx.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	holdID := 0
+	if reservationID != 0 {
+		err := work.QueryRow(ctx, `
+			UPDATE stock_reservations
+			SET order_id = $3, shipping_province = NULLIF($4, ''),
+			    expires_at = NOW() + make_interval(secs => $5), updated_at = NOW()
+			WHERE id = $1 AND buyer_id = $2 AND order_id IS NULL
+			  AND status = 'active' AND expires_at > NOW()
+			RETURNING id`,
+			reservationID, buyerID, orderID, province, UnpaidOrderTTL.Seconds()).Scan(&holdID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return false, err
+		}
+	}
+	if holdID != 0 {
+		// The order's lines replace what was reserved at checkout.
+		_, err := work.Exec(ctx, `DELETE FROM stock_reservation_items WHERE reservation_id = $1`, holdID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var shortages []Shortage
+	for _, variantID := range variantIDs {
+		var available int
+		err := work.QueryRow(ctx, `
+			SELECT available_to_sell FROM variant_availability WHERE variant_id = $1`,
+			variantID).Scan(&available)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrUnknownVariant
+		}
+		if err != nil {
+			return false, err
+		}
+		if available < totals[variantID] {
+			shortages = append(shortages, Shortage{VariantID: variantID, Requested: totals[variantID], Available: available})
+		}
+	}
+	if len(shortages) > 0 {
+		if err := work.Rollback(ctx); err != nil {
+			return false, err
+		}
+		if err := recordRejection(ctx, tx, orderID, shortages); err != nil {
+			return false, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return false, err
+		}
+		return false, &InsufficientStockError{Shortages: shortages}
+	}
+
+	if holdID == 0 {
+		err := work.QueryRow(ctx, `
+			INSERT INTO stock_reservations (buyer_id, order_id, shipping_province, expires_at)
+			VALUES ($1, $2, NULLIF($3, ''), NOW() + make_interval(secs => $4))
+			RETURNING id`, buyerID, orderID, province, UnpaidOrderTTL.Seconds()).Scan(&holdID)
+		if err != nil {
+			return false, err
+		}
+	}
+	if err := reserveItems(ctx, work, holdID, totals, variantIDs); err != nil {
+		return false, err
+	}
+
+	if err := work.Commit(ctx); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	publishAvailability(ctx, variantIDs)
+	return true, nil
+}
+
+// ApplyOrder takes the stock an order holds, decrementing every line in one
+// transaction. It returns false without changing anything if the order was
+// already applied, ErrNoHold if the order has no hold yet,
+// ErrHoldExpired if its hold expired and ErrOrderCancelled if the order
+// was cancelled before it was applied. Lines of preorder and backorder
+// variants may take stock below zero, up to the variant's backorder limit;
+// the part not covered by stock on hand is returned as backorders. Each
+// line is allocated to locations using the configured strategy and the
+// buyer's province.
+//
+// Stock changed by hand while the order was held can still leave a line
+// short; the order is then rejected with an *InsufficientStockError and
+// its hold released, like an order that could not be held.
+func ApplyOrder(ctx context.Context, orderID int) (bool, []Backorder, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOrder(ctx, tx, orderID); err != nil {
+		return false, nil, err
+	}
+	if err := checkCancelled(ctx, tx, orderID); err != nil {
+		return false, nil, err
+	}
+
+	var holdID int
+	var status, province string
+	var live bool
+	err = tx.QueryRow(ctx, `
+		SELECT id, status, expires_at > NOW(), COALESCE(shipping_province, '')
+		FROM stock_reservations
+		WHERE order_id = $1
+		FOR UPDATE`, orderID).Scan(&holdID, &status, &live, &province)
+	if errors.Is(err, pgx.ErrNoRows) {
+		rejected, err := rejection(ctx, tx, orderID)
+		if err != nil {
+			return false, nil, err
+		}
+		if rejected != nil {
+			return false, nil, rejected
+		}
+		return false, nil, ErrNoHold
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	switch {
+	case status == "committed":
+		return false, nil, nil
+	case status != "active" || !live:
+		return false, nil, ErrHoldExpired
+	}
+
+	items, err := reservationItems(ctx, tx, holdID)
+	if err != nil {
+		return false, nil, err
+	}
+	totals, variantIDs := aggregate(items)
+	if err := lockVariants(ctx, tx, variantIDs); err != nil {
+		return false, nil, err
+	}
+
+	// The order's changes go in a savepoint, so a rejection can undo them
+	// and still be recorded.
+	work, err := tx.Begin(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Committing the hold stops it counting as reserved, so the order can
+	// take the stock it held.
+	_, err = work.Exec(ctx, `
+		UPDATE stock_reservations SET status = 'committed', updated_at = NOW() WHERE id = $1`, holdID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	ref := strconv.Itoa(orderID)
+	var shortages []Shortage
+	var backorders []Backorder
+	for _, variantID := range variantIDs {
+		var onHand, reserved, limit int
+		b := Backorder{VariantID: variantID}
+		err := work.QueryRow(ctx, `
+			SELECT a.on_hand, a.reserved, v.fulfilment_mode, v.backorder_limit, v.expected_ship_date::text
+			FROM variant_availability a
+			JOIN product_variants v ON v.id = a.variant_id
+			WHERE a.variant_id = $1`,
+			variantID).Scan(&onHand, &reserved, &b.FulfilmentMode, &limit, &b.ExpectedShipDate)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil, ErrUnknownVariant
+		}
+		if err != nil {
+			return false, nil, err
+		}
+		if b.FulfilmentMode == ModeInStock {
+			limit = 0
+		}
+
+		quantity := totals[variantID]
+		applied, err := record(ctx, work, Movement{
+			VariantID: variantID,
+			Delta:     -quantity,
+			Reason:    ReasonSale,
+			Reference: ref,
+			Actor:     ActorSystem,
+		}, reserved-limit)
+		if errors.Is(err, errShort) {
+			available := onHand - reserved + limit
+			if available < 0 {
+				available = 0
+			}
+			shortages = append(shortages, Shortage{VariantID: variantID, Requested: quantity, Available: available})
+			continue
+		}
+		if err != nil {
+			return false, nil, err
+		}
+		if !applied {
+			// The unique key only lets a redelivery conflict if the whole
+			// order was committed before.
+			return false, nil, nil
+		}
+
+		onShelf := onHand - reserved
+		if onShelf < 0 {
+			onShelf = 0
+		}
+		if onShelf > quantity {
+			onShelf = quantity
+		}
+		if onShelf > 0 {
+			if err := allocate(ctx, work, orderID, variantID, onShelf, province); err != nil {
+				return false, nil, err
+			}
+		}
+		if b.Quantity = quantity - onShelf; b.Quantity > 0 {
+			if err := openBackorder(ctx, work, orderID, b); err != nil {
+				return false, nil, err
+			}
+			backorders = append(backorders, b)
+		}
+	}
+	if len(shortages) > 0 {
+		if err := work.Rollback(ctx); err != nil {
+			return false, nil, err
+		}
+		_, err := tx.Exec(ctx, `
+			UPDATE stock_reservations SET status = 'released', updated_at = NOW() WHERE id = $1`, holdID)
+		if err != nil {
+			return false, nil, err
+		}
+		if err := recordRejection(ctx, tx, orderID, shortages); err != nil {
+			return false, nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return false, nil, err
+		}
+		publishAvailability(ctx, variantIDs)
+		return false, nil, &InsufficientStockError{Shortages: shortages}
+	}
+
+	if err := work.Commit(ctx); err != nil {
+		return false, nil, err
+	}
+	return true, backorders, tx.Commit(ctx)
+}
+
+// Advisory lock namespaces, keyed by order and buyer ID.
+const (
+	orderLockClass = 1
+	buyerLockClass = 2
+)
+
+// lockOrder serializes the transactions that hold, take or put back the
+// stock of one order until tx ends. Holding and cancelling an order touch
+// no common row until the hold exists, so row locks alone cannot order
+// them.
+func lockOrder(ctx context.Context, tx pgx.Tx, orderID int) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, orderLockClass, orderID)
+	return err
+}
+
+// checkCancelled returns ErrOrderCancelled if the order was cancelled.
+func checkCancelled(ctx context.Context, tx pgx.Tx, orderID int) error {
+	var cancelled bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM cancelled_orders WHERE order_id = $1)`, orderID).Scan(&cancelled)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		return ErrOrderCancelled
+	}
+	return nil
+}
+
+// rejection returns the recorded rejection of an order, or nil.
+func rejection(ctx context.Context, tx pgx.Tx, orderID int) (*InsufficientStockError, error) {
+	var shortages []Shortage
+	err := tx.QueryRow(ctx, `SELECT shortages FROM rejected_orders WHERE order_id = $1`, orderID).Scan(&shortages)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &InsufficientStockError{Shortages: shortages}, nil
+}
+
+func recordRejection(ctx context.Context, tx pgx.Tx, orderID int, shortages []Shortage) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO rejected_orders (order_id, shortages) VALUES ($1, $2)
+		ON CONFLICT (order_id) DO NOTHING`, orderID, shortages)
+	return err
+}
+
+// aggregate sums quantities per variant and returns the variant IDs in a
+// fixed order, so concurrent writers lock variant rows consistently.
+func aggregate(items []OrderItem) (map[int]int, []int) {
+	totals := map[int]int{}
+	for _, item := range items {
+		totals[item.VariantID] += item.Quantity
+	}
+	variantIDs := make([]int, 0, len(totals))
+	for id := range totals {
+		variantIDs = append(variantIDs, id)
+	}
+	sort.Ints(variantIDs)
+	return totals, variantIDs
+}
+
+// lockVariants takes the row locks that serialise stock and reservation
+// changes on the given variants.
+func lockVariants(ctx context.Context, tx pgx.Tx, variantIDs []int) error {
+	_, err := tx.Exec(ctx, `
+		SELECT id FROM product_variants WHERE id = ANY($1) ORDER BY id FOR UPDATE`, variantIDs)
+	return err
+}
+
+// record inserts a movement and applies its delta to the variant and, if
+// the movement has one, to its location, where stock received first goes
+// to open backorders; without a location the caller updates
+// location_stock itself. It returns false if the movement already
+// exists, and errShort if a decrement would take stock below floor; the
+// caller must then roll back.
+func record(ctx context.Context, tx pgx.Tx, m Movement, floor int) (bool, error) {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO stock_movements (variant_id, location_id, delta, reason, reference_id, actor, note)
+		VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''))
+		ON CONFLICT ON CONSTRAINT stock_movements_once DO NOTHING`,
+		m.VariantID, m.LocationID, m.Delta, m.Reason, m.Reference, m.Actor, m.Note)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return false, ErrUnknownVariant
+		}
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	// Conditional rather than relying on the check constraint, so a short
+	// variant does not abort the transaction before the others are checked.
+	tag, err = tx.Exec(ctx, `
+		UPDATE product_variants
+		SET stock_quantity = stock_quantity + $2, updated_at = NOW()
+		WHERE id = $1 AND ($2 >= 0 OR stock_quantity + $2 >= $3)`, m.VariantID, m.Delta, floor)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, errShort
+	}
+
+	switch {
+	case m.LocationID != 0 && m.Delta > 0:
+		err = receive(ctx, tx, m.LocationID, m.VariantID, m.Delta)
+	case m.LocationID != 0:
+		err = adjustLocation(ctx, tx, m.LocationID, m.VariantID, m.Delta)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetQuantity brings a variant's total stock to quantity inside tx,
+// recording the difference as a manual movement. It is how sellers and
+// imports change stock; nothing is recorded if the quantity is unchanged.
+// Increases go to the seller's default location, decreases come out of it
+// first and then out of the fullest locations.
+func SetQuantity(ctx context.Context, tx pgx.Tx, variantID, quantity int, reason, actor string) error {
+	var current int
+	err := tx.QueryRow(ctx, `
+		SELECT stock_quantity FROM product_variants WHERE id = $1 FOR UPDATE`, variantID).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUnknownVariant
+	}
+	if err != nil {
+		return err
+	}
+	if quantity == current {
+		return nil
+	}
+
+	m := Movement{
+		VariantID: variantID,
+		Delta:     quantity - current,
+		Reason:    reason,
+		Actor:     actor,
+	}
+	if m.Delta > 0 {
+		if m.LocationID, err = variantDefaultLocation(ctx, tx, variantID); err != nil {
+			return err
+		}
+	}
+	if _, err := record(ctx, tx, m, 0); err != nil {
+		return err
+	}
+	if m.Delta < 0 {
+		return reduceLocations(ctx, tx, variantID, -m.Delta)
+	}
+	return nil
+}
+
+// Restock puts back stock taken by an order, recording each variant's
+// movement under reason and ref so the same event restocks only once.
+// With no items the whole remaining quantity of the order is restocked;
+// otherwise each item is capped at what the order took and has not yet
+// been returned. It returns false if the event was already applied.
+func Restock(ctx context.Context, orderID int, reason, ref, note string, items []OrderItem) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	restocked, err := restock(ctx, tx, orderID, reason, ref, note, items)
+	if err != nil || !restocked {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// CancelOrder puts back the stock of a cancelled order: a hold it still
+// has is released and whatever it took is restocked, like Restock with
+// ReasonRestock. The cancellation is recorded, so an order.placed or
+// order.confirmed handled later holds and takes nothing. It returns false
+// if there was nothing to put back, e.g. for a redelivered cancellation.
+func CancelOrder(ctx context.Context, orderID int, note string, items []OrderItem) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Waits for a HoldOrder or ApplyOrder of the order, so the release and
+	// restock below see what it committed.
+	if err := lockOrder(ctx, tx, orderID); err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO cancelled_orders (order_id) VALUES ($1)
+		ON CONFLICT (order_id) DO NOTHING`, orderID)
+	if err != nil {
+		return false, err
+	}
+	recorded := tag.RowsAffected() > 0
+
+	released, err := releaseHold(ctx, tx, orderID)
+	if err != nil {
+		return false, err
+	}
+
+	// The restock goes in a savepoint: one already applied leaves nothing
+	// to commit but must not undo the release.
+	work, err := tx.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	restocked, err := restock(ctx, work, orderID, ReasonRestock, strconv.Itoa(orderID), note, items)
+	if err != nil {
+		return false, err
+	}
+	if restocked {
+		err = work.Commit(ctx)
+	} else {
+		err = work.Rollback(ctx)
+	}
+	if err != nil {
+		return false, err
+	}
+	if !recorded && len(released) == 0 && !restocked {
+		return false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	if len(released) > 0 {
+		publishAvailability(ctx, released)
+		if err := openReleasedWaves(ctx, released); err != nil {
+			log.Printf("❌ Failed to open restock waves: %v", err)
+		}
+	}
+	return len(released) > 0 || restocked, nil
+}
+
+func restock(ctx context.Context, tx pgx.Tx, orderID int, reason, ref, note string, items []OrderItem) (bool, error) {
+	orderRef := strconv.Itoa(orderID)
+	rows, err := tx.Query(ctx, `
+		SELECT variant_id,
+		       -SUM(delta) FILTER (WHERE reason = $2) -
+		       COALESCE(SUM(delta) FILTER (WHERE reason = ANY($3)), 0)
+		FROM stock_movements
+		WHERE reference_id = $1 OR reference_id LIKE $1 || '/%'
+		GROUP BY variant_id
+		HAVING SUM(delta) FILTER (WHERE reason = $2) IS NOT NULL`,
+		orderRef, ReasonSale, restockReasons)
+	if err != nil {
+		return false, err
+	}
+	remaining := map[int]int{}
+	for rows.Next() {
+		var variantID, qty int
+		if err := rows.Scan(&variantID, &qty); err != nil {
+			rows.Close()
+			return false, err
+		}
+		remaining[variantID] = qty
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	if items == nil {
+		for variantID, qty := range remaining {
+			items = append(items, OrderItem{VariantID: variantID, Quantity: qty})
+		}
+	}
+	totals, variantIDs := aggregate(items)
+	if err := lockVariants(ctx, tx, variantIDs); err != nil {
+		return false, err
+	}
+
+	restocked := false
+	for _, variantID := range variantIDs {
+		qty := totals[variantID]
+		if qty > remaining[variantID] {
+			qty = remaining[variantID]
+		}
+		if qty <= 0 {
+			continue
+		}
+
+		applied, err := record(ctx, tx, Movement{
+			VariantID: variantID,
+			Delta:     qty,
+			Reason:    reason,
+			Reference: ref,
+			Actor:     ActorSystem,
+			Note:      note,
+		}, 0)
+		if err != nil {
+			return false, err
+		}
+		if !applied {
+			return false, nil
+		}
+
+		// Units still on backorder never left a location; only the rest
+		// goes back on a shelf.
+		cancelled, err := cancelBackorder(ctx, tx, orderID, variantID, qty)
+		if err != nil {
+			return false, err
+		}
+		if qty > cancelled {
+			locationID, err := restockLocation(ctx, tx, orderID, variantID)
+			if err != nil {
+				return false, err
+			}
+			if err := receive(ctx, tx, locationID, variantID, qty-cancelled); err != nil {
+				return false, err
+			}
+		}
+		restocked = true
+	}
+	// Nothing left to put back is e.g. an order rejected for stock.
+	return restocked, nil
+}
@@ -0,0 +1,157 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"InventoryService/db"
+	"InventoryService/events"
+)
+
+func TestHoldOrderRejectsNonPositiveQuantity(t *testing.T) {
+	for _, quantity := range []int{0, -3} {
+		items := []OrderItem{{VariantID: 1, Quantity: 2}, {VariantID: 2, Quantity: quantity}}
+		_, err := HoldOrder(context.Background(), 10, 7, 0, "", items)
+		if !errors.Is(err, ErrInvalidQuantity) {
+			t.Errorf("quantity %d: got %v, want ErrInvalidQuantity", quantity, err)
+		}
+	}
+}
+
+// testDB connects db.Pool to the migrated inventorydb in
+// INVENTORY_TEST_DB_URL, skipping the test without one.
+func testDB(t *testing.T) context.Context {
+	t.Helper()
+	url := os.Getenv("INVENTORY_TEST_DB_URL")
+	if url == "" {
+		t.Skip("INVENTORY_TEST_DB_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	db.Pool = pool
+	events.Init()
+	return ctx
+}
+
+// testVariant creates a listed variant with stock on hand.
+func testVariant(t *testing.T, ctx context.Context, stock int) int {
+	t.Helper()
+	var productID, variantID int
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO products (name, base_price, image, seller_id, seller_username, listed)
+		VALUES ('Test product', 100, 'test.png', 1, 'seller', TRUE)
+		RETURNING id`).Scan(&productID)
+	if err != nil {
+		t.Fatalf("insert product: %v", err)
+	}
+	err = db.Pool.QueryRow(ctx, `
+		INSERT INTO product_variants (product_id, stock_quantity, image, sku)
+		VALUES ($1, $2, 'test.png', '')
+		RETURNING id`, productID, stock).Scan(&variantID)
+	if err != nil {
+		t.Fatalf("insert variant: %v", err)
+	}
+	return variantID
+}
+
+func availableToSell(t *testing.T, ctx context.Context, variantID int) int {
+	t.Helper()
+	var available int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT available_to_sell FROM variant_availability WHERE variant_id = $1`, variantID).Scan(&available)
+	if err != nil {
+		t.Fatalf("availability of variant %d: %v", variantID, err)
+	}
+	return available
+}
+
+func TestCancelOrderReleasesHold(t *testing.T) {
+	ctx := testDB(t)
+	variantID := testVariant(t, ctx, 5)
+	orderID := int(time.Now().UnixNano() % 1e9)
+
+	held, err := HoldOrder(ctx, orderID, 7, 0, "", []OrderItem{{VariantID: variantID, Quantity: 3}})
+	if err != nil || !held {
+		t.Fatalf("HoldOrder = %v, %v; want true, nil", held, err)
+	}
+	if got := availableToSell(t, ctx, variantID); got != 2 {
+		t.Fatalf("available while held = %d, want 2", got)
+	}
+
+	cancelled, err := CancelOrder(ctx, orderID, "order cancelled", nil)
+	if err != nil || !cancelled {
+		t.Fatalf("CancelOrder = %v, %v; want true, nil", cancelled, err)
+	}
+	if got := availableToSell(t, ctx, variantID); got != 5 {
+		t.Errorf("available after cancel = %d, want 5", got)
+	}
+	var status string
+	err = db.Pool.QueryRow(ctx, `SELECT status FROM stock_reservations WHERE order_id = $1`, orderID).Scan(&status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != "released" {
+		t.Errorf("hold status = %q, want released", status)
+	}
+
+	// A redelivered cancellation finds nothing left to put back.
+	if cancelled, err := CancelOrder(ctx, orderID, "order cancelled", nil); err != nil || cancelled {
+		t.Errorf("second CancelOrder = %v, %v; want false, nil", cancelled, err)
+	}
+	if _, _, err := ApplyOrder(ctx, orderID); !errors.Is(err, ErrOrderCancelled) {
+		t.Errorf("ApplyOrder after cancel: got %v, want ErrOrderCancelled", err)
+	}
+}
+
+func TestOrderPlacedAfterCancellationHoldsNothing(t *testing.T) {
+	ctx := testDB(t)
+	variantID := testVariant(t, ctx, 5)
+	orderID := int(time.Now().UnixNano() % 1e9)
+
+	// order.cancelled handled while order.placed waits on a retry queue.
+	if cancelled, err := CancelOrder(ctx, orderID, "order cancelled", nil); err != nil || cancelled {
+		t.Fatalf("CancelOrder = %v, %v; want false, nil", cancelled, err)
+	}
+
+	_, err := HoldOrder(ctx, orderID, 7, 0, "", []OrderItem{{VariantID: variantID, Quantity: 3}})
+	if !errors.Is(err, ErrOrderCancelled) {
+		t.Fatalf("HoldOrder after cancel: got %v, want ErrOrderCancelled", err)
+	}
+	if _, _, err := ApplyOrder(ctx, orderID); !errors.Is(err, ErrOrderCancelled) {
+		t.Errorf("ApplyOrder after cancel: got %v, want ErrOrderCancelled", err)
+	}
+	if got := availableToSell(t, ctx, variantID); got != 5 {
+		t.Errorf("available = %d, want 5", got)
+	}
+}
+
+func TestReserveLimitsBuyer(t *testing.T) {
+	ctx := testDB(t)
+	variantID := testVariant(t, ctx, MaxReservedUnits*2)
+	buyerID := int(time.Now().UnixNano() % 1e9)
+
+	for i := 0; i < MaxActiveReservations; i++ {
+		if _, err := Reserve(ctx, buyerID, []OrderItem{{VariantID: variantID, Quantity: 1}}, time.Minute); err != nil {
+			t.Fatalf("reservation %d: %v", i+1, err)
+		}
+	}
+	_, err := Reserve(ctx, buyerID, []OrderItem{{VariantID: variantID, Quantity: 1}}, time.Minute)
+	if !errors.Is(err, ErrReservationLimit) {
+		t.Errorf("reservation past the count limit: got %v, want ErrReservationLimit", err)
+	}
+
+	other := buyerID + 1
+	_, err = Reserve(ctx, other, []OrderItem{{VariantID: variantID, Quantity: MaxReservedUnits + 1}}, time.Minute)
+	if !errors.Is(err, ErrReservationLimit) {
+		t.Errorf("reservation past the unit limit: got %v, want ErrReservationLimit", err)
+	}
+}
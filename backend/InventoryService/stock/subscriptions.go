@@ -0,0 +1,320 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"InventoryService/db"
+)
+
+// Back-in-stock notifications go out in batches so the oldest subscribers
+// hear first and a popular restock does not send every buyer to checkout at
+// once. Each run notifies at most restockBatchSize subscribers per variant,
+// and no more than restockBuyersPerUnit per unit available, capped at
+// restockNotifyPerRun across all variants.
+const (
+	restockBatchSize     = 50
+	restockBuyersPerUnit = 3
+	restockNotifyPerRun  = 500
+)
+
+var (
+	ErrInStock              = errors.New("variant is in stock")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+type Subscription struct {
+	ID         int        `json:"id"`
+	VariantID  int        `json:"variant_id"`
+	ProductID  int        `json:"product_id"`
+	SKU        string     `json:"sku"`
+	Position   int        `json:"position"`
+	CreatedAt  time.Time  `json:"created_at"`
+	NotifiedAt *time.Time `json:"notified_at"`
+}
+
+// BackInStock tells one batch of subscribers that a variant can be bought
+// again.
+type BackInStock struct {
+	Type       string    `json:"type"`
+	WaveID     int       `json:"wave_id"`
+	VariantID  int       `json:"variant_id"`
+	ProductID  int       `json:"product_id"`
+	SKU        string    `json:"sku"`
+	Available  int       `json:"available_to_sell"`
+	BuyerIDs   []int     `json:"buyer_ids"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+const subscriptionColumns = `s.id, s.variant_id, v.product_id, v.sku,
+	(SELECT COUNT(*) FROM restock_subscriptions q
+	 WHERE q.variant_id = s.variant_id AND q.notified_at IS NULL
+	   AND (q.created_at, q.id) <= (s.created_at, s.id))::INTEGER,
+	s.created_at, s.notified_at`
+
+func scanSubscription(row pgx.Row) (*Subscription, error) {
+	var s Subscription
+	err := row.Scan(&s.ID, &s.VariantID, &s.ProductID, &s.SKU, &s.Position, &s.CreatedAt, &s.NotifiedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	return &s, err
+}
+
+// Subscribe queues buyerID for a back-in-stock notification on a variant
+// that cannot be bought right now. Subscribing twice keeps the original
+// place in the queue.
+func Subscribe(ctx context.Context, buyerID, variantID int) (*Subscription, error) {
+	var available int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT available_to_sell FROM variant_availability WHERE variant_id = $1`, variantID).Scan(&available)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUnknownVariant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if available > 0 {
+		return nil, ErrInStock
+	}
+
+	_, err = db.Pool.Exec(ctx, `
+		INSERT INTO restock_subscriptions (variant_id, buyer_id)
+		VALUES ($1, $2)
+		ON CONFLICT (variant_id, buyer_id) WHERE notified_at IS NULL DO NOTHING`, variantID, buyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscription(db.Pool.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM restock_subscriptions s
+		JOIN product_variants v ON v.id = s.variant_id
+		WHERE s.variant_id = $1 AND s.buyer_id = $2 AND s.notified_at IS NULL`, variantID, buyerID))
+}
+
+// Unsubscribe drops the buyer's pending subscription to a variant.
+func Unsubscribe(ctx context.Context, buyerID, variantID int) error {
+	tag, err := db.Pool.Exec(ctx, `
+		DELETE FROM restock_subscriptions
+		WHERE variant_id = $1 AND buyer_id = $2 AND notified_at IS NULL`, variantID, buyerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// Subscriptions lists the buyer's pending subscriptions with their place
+// in each variant's queue.
+func Subscriptions(ctx context.Context, buyerID int) ([]Subscription, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM restock_subscriptions s
+		JOIN product_variants v ON v.id = s.variant_id
+		WHERE s.buyer_id = $1 AND s.notified_at IS NULL
+		ORDER BY s.created_at DESC`, buyerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// OpenRestockWave starts notifying the variant's subscribers after it was
+// restocked from zero at the given row version. Nothing is opened if nobody
+// is waiting or a wave is already open.
+func OpenRestockWave(ctx context.Context, variantID, version int) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO restock_waves (variant_id, version)
+		SELECT $1, $2
+		WHERE EXISTS (SELECT 1 FROM restock_subscriptions WHERE variant_id = $1 AND notified_at IS NULL)
+		  AND NOT EXISTS (SELECT 1 FROM restock_waves WHERE variant_id = $1 AND closed_at IS NULL)
+		ON CONFLICT DO NOTHING`, variantID, version)
+	return err
+}
+
+// openReleasedWaves starts notifying the subscribers of each variant that
+// closed reservations made available again. Buyers can subscribe while
+// everything on hand is held by checkouts, so waiting for the next restock
+// from zero would leave them waiting while the stock is sold.
+func openReleasedWaves(ctx context.Context, variantIDs []int) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO restock_waves (variant_id)
+		SELECT a.variant_id FROM variant_availability a
+		WHERE a.variant_id = ANY($1) AND a.available_to_sell > 0
+		  AND EXISTS (SELECT 1 FROM restock_subscriptions s WHERE s.variant_id = a.variant_id AND s.notified_at IS NULL)
+		  AND NOT EXISTS (SELECT 1 FROM restock_waves w WHERE w.variant_id = a.variant_id AND w.closed_at IS NULL)
+		ON CONFLICT DO NOTHING`, variantIDs)
+	return err
+}
+
+// NotifyRestocks sends the next batch of every open wave to publish, oldest
+// wave first. A batch is only marked notified once publish succeeds.
+func NotifyRestocks(ctx context.Context, publish func(BackInStock) error) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id FROM restock_waves WHERE closed_at IS NULL ORDER BY opened_at, id`)
+	if err != nil {
+		return err
+	}
+	var waveIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		waveIDs = append(waveIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	budget := restockNotifyPerRun
+	for _, waveID := range waveIDs {
+		if budget == 0 {
+			break
+		}
+		sent, err := notifyWave(ctx, waveID, budget, publish)
+		if err != nil {
+			return err
+		}
+		budget -= sent
+	}
+	return nil
+}
+
+// notifyWave sends one batch of at most limit subscribers for a wave and
+// returns how many were notified.
+func notifyWave(ctx context.Context, waveID, limit int, publish func(BackInStock) error) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	event := BackInStock{Type: "back_in_stock", WaveID: waveID}
+	err = tx.QueryRow(ctx, `
+		SELECT variant_id FROM restock_waves
+		WHERE id = $1 AND closed_at IS NULL
+		FOR UPDATE SKIP LOCKED`, waveID).Scan(&event.VariantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Closed or being handled by another run.
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var onHand int
+	err = tx.QueryRow(ctx, `
+		SELECT v.product_id, v.sku, a.on_hand, a.available_to_sell
+		FROM product_variants v
+		JOIN variant_availability a ON a.variant_id = v.id
+		WHERE v.id = $1`, event.VariantID).Scan(&event.ProductID, &event.SKU, &onHand, &event.Available)
+	if err != nil {
+		return 0, err
+	}
+
+	if onHand == 0 {
+		// Sold out again; the rest wait for the next restock.
+		return 0, closeWave(ctx, tx, waveID)
+	}
+	if event.Available == 0 {
+		// Everything left is held by checkouts; try again next run.
+		return 0, nil
+	}
+
+	batch := restockBatchSize
+	if n := event.Available * restockBuyersPerUnit; n < batch {
+		batch = n
+	}
+	if limit < batch {
+		batch = limit
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE restock_subscriptions SET notified_at = NOW()
+		WHERE id IN (
+			SELECT id FROM restock_subscriptions
+			WHERE variant_id = $1 AND notified_at IS NULL
+			ORDER BY created_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING buyer_id`, event.VariantID, batch)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var buyerID int
+		if err := rows.Scan(&buyerID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		event.BuyerIDs = append(event.BuyerIDs, buyerID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(event.BuyerIDs) == 0 {
+		return 0, closeWave(ctx, tx, waveID)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE restock_waves SET notified = notified + $2 WHERE id = $1`, waveID, len(event.BuyerIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	event.OccurredAt = time.Now().UTC()
+	if err := publish(event); err != nil {
+		log.Printf("❌ Failed to publish back_in_stock for variant %d: %v", event.VariantID, err)
+		return 0, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	log.Printf("🔔 Told %d subscriber(s) variant %d is back in stock", len(event.BuyerIDs), event.VariantID)
+	return len(event.BuyerIDs), nil
+}
+
+func closeWave(ctx context.Context, tx pgx.Tx, waveID int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE restock_waves SET closed_at = NOW() WHERE id = $1`, waveID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// StartRestockNotifier sends back-in-stock batches every interval, which
+// is what spaces consecutive batches of the same variant apart.
+func StartRestockNotifier(interval time.Duration, publish func(BackInStock) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := NotifyRestocks(context.Background(), publish); err != nil {
+			log.Printf("❌ Back-in-stock run failed: %v", err)
+		}
+	}
+}
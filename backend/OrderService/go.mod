@@ -8,9 +8,12 @@ require (
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/machinebox/graphql v0.2.2
 	github.com/streadway/amqp v1.1.0
+	messaging v0.0.0
 )
 
 require (
 	github.com/matryer/is v1.4.1 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 )
+
+replace messaging => ../messaging
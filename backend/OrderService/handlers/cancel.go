@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"orderservice/orders"
+)
+
+const maxCancellationReasonLength = 500
+
+// CancelOrderHandler handles POST /orders/{id}/cancel for the order's buyer
+// or seller. Only pending orders can be cancelled; the stock is put back by
+// InventoryService once the order.cancelled event the status change writes
+// to the outbox is relayed.
+func CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := extractUserIDFromToken(r.Header.Get("Authorization"))
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.Reason) > maxCancellationReasonLength {
+		http.Error(w, "Reason is too long", http.StatusBadRequest)
+		return
+	}
+
+	order, err := orders.Get(r.Context(), orderID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch order %d: %v", orderID, err)
+		http.Error(w, "Failed to cancel order", http.StatusInternalServerError)
+		return
+	}
+	if order == nil || (order.BuyerID != userID && order.SellerID != userID) {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Cancelled by the seller."
+		if order.BuyerID == userID {
+			reason = "Cancelled by the buyer."
+		}
+	}
+
+	cancelled, err := orders.CancelPending(r.Context(), orderID, reason)
+	if err != nil {
+		log.Printf("❌ Failed to cancel order %d: %v", orderID, err)
+		http.Error(w, "Failed to cancel order", http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		http.Error(w, "Only pending orders can be cancelled", http.StatusConflict)
+		return
+	}
+
+	log.Printf("🚫 Order %d cancelled by user %d", orderID, userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "order cancelled",
+		"id":     orderID,
+	})
+}
@@ -11,22 +11,25 @@ import (
 
 	"github.com/golang-jwt/jwt"
 	gql "github.com/machinebox/graphql"
+	"messaging/contracts"
 
 	"orderservice/graphql"
-	"orderservice/rabbitmq"
+	"orderservice/outbox"
 )
 
 // Request payload for creating an order
 type CreateOrderRequest struct {
-	BuyerID         int              `json:"buyer_id"`
-	BuyerName       string           `json:"buyer_name"`
-	SellerID        int              `json:"seller_id"`
-	SellerUsername  string           `json:"seller_username"`
-	ShippingMethod  string           `json:"shipping_method"`
-	ShippingAddress string           `json:"shipping_address"`
-	ContactNumber   string           `json:"contact_number"`
-	PaymentMethod   string           `json:"payment_method"`
-	OrderItems      []OrderItemInput `json:"order_items"`
+	BuyerID          int              `json:"buyer_id"`
+	BuyerName        string           `json:"buyer_name"`
+	SellerID         int              `json:"seller_id"`
+	SellerUsername   string           `json:"seller_username"`
+	ShippingMethod   string           `json:"shipping_method"`
+	ShippingAddress  string           `json:"shipping_address"`
+	ShippingProvince string           `json:"shipping_province"` // picks the shipping location, optional
+	ContactNumber    string           `json:"contact_number"`
+	PaymentMethod    string           `json:"payment_method"`
+	ReservationID    int              `json:"reservation_id"` // from InventoryService checkout, optional
+	OrderItems       []OrderItemInput `json:"order_items"`
 }
 
 type OrderItemInput struct {
@@ -42,7 +45,8 @@ type OrderItemInput struct {
 	ImageURL    string  `json:"image_url"`
 }
 
-// Handles order creation and RabbitMQ stock message publishing
+// Handles order creation. The order.placed event that takes its stock is
+// written to the outbox in the same insert and published by the relay.
 func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("📨 /create-order endpoint hit")
 
@@ -65,6 +69,30 @@ func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
 
 	req.BuyerID = userID
 
+	var items []outbox.Item
+	for _, i := range req.OrderItems {
+		items = append(items, outbox.Item{
+			VariantID: i.VariantID,
+			Quantity:  i.Quantity,
+		})
+	}
+	placed := outbox.OrderPlaced{
+		BuyerID:          req.BuyerID,
+		ReservationID:    req.ReservationID,
+		ShippingProvince: req.ShippingProvince,
+		// Cash on delivery is paid when the order arrives; it need not
+		// wait for payment to take its stock.
+		AwaitingPayment: req.PaymentMethod != "cod",
+		Items:           items,
+	}
+
+	placedEvent, err := outbox.Insert(contracts.OrderPlaced, placed)
+	if err != nil {
+		log.Printf("❌ Failed to queue order.placed: %v", err)
+		http.Error(w, "Order creation failed", http.StatusInternalServerError)
+		return
+	}
+
 	// GraphQL mutation to insert the order via Hasura
 	mutation := `
 	mutation CreateOrder($order: orders_insert_input!) {
@@ -88,6 +116,7 @@ func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
 		"order_items": map[string]interface{}{
 			"data": req.OrderItems,
 		},
+		"outbox_events": placedEvent,
 	}
 
 	reqBody := gql.NewRequest(mutation)
@@ -106,25 +135,7 @@ func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Publish to RabbitMQ for inventory stock update
-	var items []rabbitmq.Item
-	for _, i := range req.OrderItems {
-		items = append(items, rabbitmq.Item{
-			VariantID: i.VariantID,
-			Quantity:  i.Quantity,
-		})
-	}
-
-	message := rabbitmq.OrderStockMessage{
-		OrderID: resp.InsertOrdersOne.ID,
-		Items:   items,
-	}
-
-	if err := rabbitmq.PublishOrderToInventoryQueue(message); err != nil {
-		log.Printf("❌ Failed to publish to inventory queue: %v", err)
-	} else {
-		log.Printf("✅ Order %d with %d items published to inventory queue", message.OrderID, len(items))
-	}
+	log.Printf("✅ Order %d with %d items created, order.placed queued in the outbox", resp.InsertOrdersOne.ID, len(items))
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -133,10 +144,10 @@ func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Extracts user ID from a Hasura JWT token
-func extractUserIDFromToken(authHeader string) (int, error) {
+// Parses a "Bearer <token>" Authorization header
+func parseToken(authHeader string) (jwt.MapClaims, error) {
 	if authHeader == "" {
-		return 0, fmt.Errorf("missing token")
+		return nil, fmt.Errorf("missing token")
 	}
 
 	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
@@ -148,12 +159,37 @@ func extractUserIDFromToken(authHeader string) (int, error) {
 	})
 
 	if err != nil || !token.Valid {
-		return 0, fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return 0, fmt.Errorf("invalid claims")
+		return nil, fmt.Errorf("invalid claims")
+	}
+	return claims, nil
+}
+
+// RequireAdmin lets only requests with an admin token through
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := parseToken(r.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if role, _ := claims["role"].(string); role != "admin" {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Extracts user ID from a Hasura JWT token
+func extractUserIDFromToken(authHeader string) (int, error) {
+	claims, err := parseToken(authHeader)
+	if err != nil {
+		return 0, err
 	}
 
 	hasuraClaims, ok := claims["https://hasura.io/jwt/claims"].(map[string]interface{})
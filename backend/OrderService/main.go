@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -9,18 +10,28 @@ import (
 
 	"orderservice/graphql"
 	"orderservice/handlers"
+	"orderservice/outbox"
+	"orderservice/rabbitmq"
 )
 
 func main() {
 	// Initialize GraphQL client for Hasura
 	graphql.InitClient("http://hasura-order:8080/v1/graphql")
 
+	// Cancel orders InventoryService could not fulfil and hold back lines
+	// that wait for stock
+	rabbitmq.Connect()
+	rabbitmq.StartInventoryConsumer()
+
+	// Publish the events orders write to the outbox
+	go rabbitmq.Client.Relay(context.Background(), outbox.Store{})
+
 	r := chi.NewRouter()
 
 	// Enable CORS for frontend
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -29,6 +40,12 @@ func main() {
 	// POST /create-order endpoint
 	r.Post("/create-order", handlers.CreateOrderHandler)
 
+	// POST /orders/{id}/cancel endpoint
+	r.Post("/orders/{id}/cancel", handlers.CancelOrderHandler)
+
+	// Dead-lettered messages of any consumer queue on the broker
+	r.With(handlers.RequireAdmin).Mount("/admin/dead-letters", rabbitmq.Client.DeadLetterHandler("/admin/dead-letters"))
+
 	log.Println("✅ OrderService is running on port :8100")
 	log.Fatal(http.ListenAndServe(":8100", r))
 }
@@ -0,0 +1,2 @@
+-- Why an order was cancelled, shown to the buyer (e.g. insufficient stock).
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS cancellation_reason TEXT;
@@ -0,0 +1,10 @@
+DROP VIEW IF EXISTS public.ready_to_ship_orders;
+DROP TRIGGER IF EXISTS order_items_sync_awaiting_stock ON public.order_items;
+DROP FUNCTION IF EXISTS public.sync_order_awaiting_stock();
+
+ALTER TABLE public.orders DROP COLUMN IF EXISTS awaiting_stock;
+ALTER TABLE public.order_items
+    DROP COLUMN IF EXISTS awaiting_stock,
+    DROP COLUMN IF EXISTS backordered_quantity,
+    DROP COLUMN IF EXISTS expected_ship_date,
+    DROP COLUMN IF EXISTS fulfilment_mode;
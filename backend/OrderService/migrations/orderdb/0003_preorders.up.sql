@@ -0,0 +1,37 @@
+-- Lines InventoryService sold as preorders or backorders wait for stock
+-- before they can ship.
+ALTER TABLE public.order_items
+    ADD COLUMN IF NOT EXISTS fulfilment_mode TEXT NOT NULL DEFAULT 'in_stock',
+    ADD COLUMN IF NOT EXISTS expected_ship_date DATE,
+    ADD COLUMN IF NOT EXISTS backordered_quantity INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS awaiting_stock BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- True while any line of the order is awaiting stock; kept in sync by
+-- the trigger below.
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS awaiting_stock BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE OR REPLACE FUNCTION public.sync_order_awaiting_stock()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE public.orders
+    SET awaiting_stock = EXISTS (
+            SELECT 1 FROM public.order_items
+            WHERE order_id = NEW.order_id AND awaiting_stock
+        ),
+        updated_at = now()
+    WHERE id = NEW.order_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_items_sync_awaiting_stock
+AFTER UPDATE OF awaiting_stock ON public.order_items
+FOR EACH ROW
+WHEN (OLD.awaiting_stock IS DISTINCT FROM NEW.awaiting_stock)
+EXECUTE FUNCTION public.sync_order_awaiting_stock();
+
+-- The seller's ready-to-ship queue: pending orders with nothing awaiting
+-- stock.
+CREATE OR REPLACE VIEW public.ready_to_ship_orders AS
+SELECT * FROM public.orders
+WHERE status = 'pending' AND NOT awaiting_stock;
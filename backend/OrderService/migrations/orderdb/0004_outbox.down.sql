@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS orders_outbox_cancelled ON public.orders;
+DROP FUNCTION IF EXISTS public.outbox_order_cancelled();
+DROP TRIGGER IF EXISTS outbox_events_set_order_id ON public.outbox_events;
+DROP FUNCTION IF EXISTS public.outbox_set_order_id();
+DROP TABLE IF EXISTS public.outbox_events;
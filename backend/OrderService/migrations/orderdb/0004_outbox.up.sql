@@ -0,0 +1,58 @@
+-- Transactional outbox: events are written in the same transaction as the
+-- order change they describe and published by OrderService's relay.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS public.outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(), -- the published event ID
+    order_id INTEGER REFERENCES public.orders(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    correlation_id TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending
+    ON public.outbox_events (next_attempt_at)
+    WHERE sent_at IS NULL;
+
+-- Events inserted together with their order (a nested insert) cannot know
+-- the order's ID yet; copy it into the payload.
+CREATE OR REPLACE FUNCTION public.outbox_set_order_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.order_id IS NOT NULL THEN
+        NEW.payload := jsonb_set(NEW.payload, '{order_id}', to_jsonb(NEW.order_id));
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_events_set_order_id
+BEFORE INSERT ON public.outbox_events
+FOR EACH ROW
+EXECUTE FUNCTION public.outbox_set_order_id();
+
+-- However an order gets cancelled (buyer, seller, stock rejection or an
+-- edit in Hasura), InventoryService is told to put its stock back.
+CREATE OR REPLACE FUNCTION public.outbox_order_cancelled()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO public.outbox_events (order_id, event_type, payload)
+    VALUES (
+        NEW.id,
+        'order.cancelled',
+        jsonb_strip_nulls(jsonb_build_object('reason', NEW.cancellation_reason))
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER orders_outbox_cancelled
+AFTER UPDATE OF status ON public.orders
+FOR EACH ROW
+WHEN (NEW.status = 'cancelled' AND OLD.status IS DISTINCT FROM 'cancelled')
+EXECUTE FUNCTION public.outbox_order_cancelled();
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS orders_outbox_confirmed ON public.orders;
+DROP FUNCTION IF EXISTS public.outbox_order_confirmed();
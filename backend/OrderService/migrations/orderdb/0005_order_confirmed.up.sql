@@ -0,0 +1,17 @@
+-- Orders awaiting payment only hold their stock; once one is paid,
+-- InventoryService is told to take it.
+CREATE OR REPLACE FUNCTION public.outbox_order_confirmed()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO public.outbox_events (order_id, event_type)
+    VALUES (NEW.id, 'order.confirmed');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER orders_outbox_confirmed
+AFTER UPDATE OF payment_status ON public.orders
+FOR EACH ROW
+WHEN (NEW.payment_status = 'paid' AND OLD.payment_status IS DISTINCT FROM 'paid'
+      AND NEW.status <> 'cancelled')
+EXECUTE FUNCTION public.outbox_order_confirmed();
@@ -0,0 +1 @@
+ALTER TABLE public.order_items DROP COLUMN IF EXISTS stock_received_at;
@@ -0,0 +1,3 @@
+-- Set when InventoryService reports a held-back line's stock arrived, so a
+-- stock.backordered handled after it does not hold the line back again.
+ALTER TABLE public.order_items ADD COLUMN IF NOT EXISTS stock_received_at TIMESTAMP WITHOUT TIME ZONE;
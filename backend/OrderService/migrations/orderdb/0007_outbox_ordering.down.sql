@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS public.outbox_events_unsent_order;
+DROP INDEX IF EXISTS public.outbox_events_pending;
+
+ALTER TABLE public.outbox_events
+    DROP COLUMN IF EXISTS parked_at,
+    DROP COLUMN IF EXISTS seq;
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending
+    ON public.outbox_events (next_attempt_at)
+    WHERE sent_at IS NULL;
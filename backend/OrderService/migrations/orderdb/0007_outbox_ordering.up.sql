@@ -0,0 +1,16 @@
+-- Keep each order's events in the order they were written and park the
+-- ones the relay can never publish. seq breaks ties between events written
+-- in the same transaction, which share occurred_at.
+ALTER TABLE public.outbox_events
+    ADD COLUMN IF NOT EXISTS seq BIGSERIAL,
+    ADD COLUMN IF NOT EXISTS parked_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS public.outbox_events_pending;
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending
+    ON public.outbox_events (next_attempt_at)
+    WHERE sent_at IS NULL AND parked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_events_unsent_order
+    ON public.outbox_events (order_id)
+    WHERE sent_at IS NULL;
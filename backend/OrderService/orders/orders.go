@@ -0,0 +1,168 @@
+package orders
+
+import (
+	"context"
+
+	gql "github.com/machinebox/graphql"
+
+	"orderservice/graphql"
+)
+
+// Item is the part of an order line needed to describe it to the buyer.
+type Item struct {
+	VariantID   int    `json:"variant_id"`
+	ProductName string `json:"product_name"`
+	VariantName string `json:"variant_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// Order is the part of an order needed to authorise changes to it.
+type Order struct {
+	ID       int    `json:"id"`
+	BuyerID  int    `json:"buyer_id"`
+	SellerID int    `json:"seller_id"`
+	Status   string `json:"status"`
+}
+
+func newRequest(query string) *gql.Request {
+	req := gql.NewRequest(query)
+	req.Header.Set("x-hasura-admin-secret", "password")
+	return req
+}
+
+// Get returns an order, or nil if it does not exist.
+func Get(ctx context.Context, orderID int) (*Order, error) {
+	req := newRequest(`
+	query Order($id: Int!) {
+		orders_by_pk(id: $id) {
+			id
+			buyer_id
+			seller_id
+			status
+		}
+	}`)
+	req.Var("id", orderID)
+
+	var resp struct {
+		Order *Order `json:"orders_by_pk"`
+	}
+	if err := graphql.GetClient().Run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Order, nil
+}
+
+// Items returns the lines of an order.
+func Items(ctx context.Context, orderID int) ([]Item, error) {
+	req := newRequest(`
+	query OrderItems($id: Int!) {
+		order_items(where: { order_id: { _eq: $id } }) {
+			variant_id
+			product_name
+			variant_name
+			quantity
+		}
+	}`)
+	req.Var("id", orderID)
+
+	var resp struct {
+		OrderItems []Item `json:"order_items"`
+	}
+	if err := graphql.GetClient().Run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.OrderItems, nil
+}
+
+// CancelPending moves an order that is still pending to cancelled and
+// records why. It returns false if the order had already moved on.
+func CancelPending(ctx context.Context, orderID int, reason string) (bool, error) {
+	req := newRequest(`
+	mutation CancelOrder($id: Int!, $reason: String!) {
+		update_orders(
+			where: { id: { _eq: $id }, status: { _eq: "pending" } },
+			_set: { status: "cancelled", cancellation_reason: $reason, updated_at: "now()" }
+		) {
+			affected_rows
+		}
+	}`)
+	req.Var("id", orderID)
+	req.Var("reason", reason)
+
+	var resp struct {
+		UpdateOrders struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"update_orders"`
+	}
+	if err := graphql.GetClient().Run(ctx, req, &resp); err != nil {
+		return false, err
+	}
+	return resp.UpdateOrders.AffectedRows > 0, nil
+}
+
+// BackorderedItem is an order line InventoryService could not cover from
+// stock on hand.
+type BackorderedItem struct {
+	VariantID        int     `json:"variant_id"`
+	Quantity         int     `json:"quantity"`
+	FulfilmentMode   string  `json:"fulfilment_mode"`
+	ExpectedShipDate *string `json:"expected_ship_date"`
+}
+
+// MarkAwaitingStock flags the backordered lines of an order, which keeps
+// the order out of the seller's ready-to-ship queue. stock.backordered and
+// stock.backorder_fulfilled arrive on different queues, so a line whose
+// stock was already reported received is not flagged again.
+func MarkAwaitingStock(ctx context.Context, orderID int, items []BackorderedItem) error {
+	for _, item := range items {
+		req := newRequest(`
+		mutation MarkAwaitingStock($order: Int!, $variant: Int!, $set: order_items_set_input!) {
+			backordered: update_order_items(
+				where: { order_id: { _eq: $order }, variant_id: { _eq: $variant } },
+				_set: $set
+			) {
+				affected_rows
+			}
+			awaiting: update_order_items(
+				where: {
+					order_id: { _eq: $order },
+					variant_id: { _eq: $variant },
+					stock_received_at: { _is_null: true }
+				},
+				_set: { awaiting_stock: true }
+			) {
+				affected_rows
+			}
+		}`)
+		req.Var("order", orderID)
+		req.Var("variant", item.VariantID)
+		req.Var("set", map[string]interface{}{
+			"fulfilment_mode":      item.FulfilmentMode,
+			"expected_ship_date":   item.ExpectedShipDate,
+			"backordered_quantity": item.Quantity,
+		})
+
+		if err := graphql.GetClient().Run(ctx, req, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkStockReceived clears the awaiting flag of an order line once its
+// stock has arrived, and records that it did.
+func MarkStockReceived(ctx context.Context, orderID, variantID int) error {
+	req := newRequest(`
+	mutation MarkStockReceived($order: Int!, $variant: Int!) {
+		update_order_items(
+			where: { order_id: { _eq: $order }, variant_id: { _eq: $variant } },
+			_set: { awaiting_stock: false, stock_received_at: "now()" }
+		) {
+			affected_rows
+		}
+	}`)
+	req.Var("order", orderID)
+	req.Var("variant", variantID)
+
+	return graphql.GetClient().Run(ctx, req, nil)
+}
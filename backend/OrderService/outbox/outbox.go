@@ -0,0 +1,243 @@
+// Package outbox reads and writes orderdb's outbox_events table through
+// Hasura. Events are inserted in the same mutation as the order change
+// they describe, or by database triggers, and published by the relay.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	gql "github.com/machinebox/graphql"
+	"messaging"
+	"messaging/contracts"
+
+	"orderservice/graphql"
+)
+
+type Item struct {
+	VariantID int `json:"variant_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// OrderPlaced asks InventoryService to hold the stock of a new order. An
+// order awaiting payment keeps it held until the order.confirmed written
+// when it is paid; the others take it at once. Its order_id is filled in
+// by the database when it is inserted with the order.
+type OrderPlaced struct {
+	BuyerID          int    `json:"buyer_id"`
+	ReservationID    int    `json:"reservation_id,omitempty"`
+	ShippingProvince string `json:"shipping_province,omitempty"`
+	AwaitingPayment  bool   `json:"awaiting_payment"`
+	Items            []Item `json:"items"`
+}
+
+// Insert is the nested insert for the outbox_events relationship of an
+// order, so the events are written in the same transaction as the order.
+// The payload is checked against the event's schema first, with the
+// order_id the database fills in, so an event the relay could never
+// publish fails the order instead.
+func Insert(eventType string, payload interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", eventType, err)
+	}
+	if _, ok := data["order_id"]; !ok {
+		data["order_id"] = 0
+	}
+	withOrderID, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := contracts.Validate(eventType, contracts.CurrentVersion(eventType), withOrderID); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", eventType, err)
+	}
+
+	return map[string]interface{}{
+		"data": []map[string]interface{}{{
+			"event_type": eventType,
+			"payload":    payload,
+		}},
+	}, nil
+}
+
+func newRequest(query string) *gql.Request {
+	req := gql.NewRequest(query)
+	req.Header.Set("x-hasura-admin-secret", "password")
+	return req
+}
+
+// Store is orderdb's outbox as seen by messaging.Relay.
+type Store struct{}
+
+type row struct {
+	ID            string          `json:"id"`
+	OrderID       *int            `json:"order_id"`
+	Seq           int64           `json:"seq"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	CorrelationID *string         `json:"correlation_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Attempts      int             `json:"attempts"`
+}
+
+// Claim pushes the next attempt of every due event out to leaseUntil in one
+// update, so a second OrderService instance does not claim them as well.
+// Orders with an unsent event that is parked or not due are left out, so
+// none of their later events overtake it.
+func (Store) Claim(ctx context.Context, leaseUntil time.Time) ([]messaging.OutboxEvent, error) {
+	now := time.Now()
+	held, err := heldOrders(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newRequest(`
+	mutation ClaimOutbox($now: timestamptz!, $lease: timestamptz!, $held: [Int!]!) {
+		update_outbox_events(
+			where: {
+				sent_at: { _is_null: true }
+				parked_at: { _is_null: true }
+				next_attempt_at: { _lte: $now }
+				_or: [{ order_id: { _is_null: true } }, { order_id: { _nin: $held } }]
+			}
+			_set: { next_attempt_at: $lease }
+		) {
+			returning {
+				id
+				order_id
+				seq
+				event_type
+				payload
+				correlation_id
+				occurred_at
+				attempts
+			}
+		}
+	}`)
+	req.Var("now", now)
+	req.Var("lease", leaseUntil)
+	req.Var("held", held)
+
+	var resp struct {
+		Update struct {
+			Returning []row `json:"returning"`
+		} `json:"update_outbox_events"`
+	}
+	if err := graphql.GetClient().Run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+
+	rows := resp.Update.Returning
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Seq < rows[j].Seq })
+
+	events := make([]messaging.OutboxEvent, 0, len(rows))
+	for _, r := range rows {
+		e := messaging.OutboxEvent{
+			ID:         r.ID,
+			Type:       r.EventType,
+			OccurredAt: r.OccurredAt,
+			Data:       r.Payload,
+			Attempts:   r.Attempts,
+		}
+		if r.OrderID != nil {
+			e.Key = strconv.Itoa(*r.OrderID)
+		}
+		if r.CorrelationID != nil {
+			e.CorrelationID = *r.CorrelationID
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// heldOrders lists the orders with an unsent event that is parked or
+// waiting for a retry or another relay's lease.
+func heldOrders(ctx context.Context, now time.Time) ([]int, error) {
+	req := newRequest(`
+	query HeldOutboxOrders($now: timestamptz!) {
+		outbox_events(
+			where: {
+				sent_at: { _is_null: true }
+				order_id: { _is_null: false }
+				_or: [{ parked_at: { _is_null: false } }, { next_attempt_at: { _gt: $now } }]
+			}
+			distinct_on: order_id
+		) {
+			order_id
+		}
+	}`)
+	req.Var("now", now)
+
+	var resp struct {
+		Events []struct {
+			OrderID int `json:"order_id"`
+		} `json:"outbox_events"`
+	}
+	if err := graphql.GetClient().Run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+
+	held := make([]int, 0, len(resp.Events))
+	for _, e := range resp.Events {
+		held = append(held, e.OrderID)
+	}
+	return held, nil
+}
+
+func (Store) MarkSent(ctx context.Context, id string) error {
+	req := newRequest(`
+	mutation MarkOutboxSent($id: uuid!, $now: timestamptz!) {
+		update_outbox_events_by_pk(
+			pk_columns: { id: $id }
+			_set: { sent_at: $now, last_error: null }
+		) {
+			id
+		}
+	}`)
+	req.Var("id", id)
+	req.Var("now", time.Now())
+	return graphql.GetClient().Run(ctx, req, nil)
+}
+
+func (Store) MarkFailed(ctx context.Context, id string, retryAt time.Time, cause error) error {
+	req := newRequest(`
+	mutation MarkOutboxFailed($id: uuid!, $retryAt: timestamptz!, $error: String!) {
+		update_outbox_events_by_pk(
+			pk_columns: { id: $id }
+			_set: { next_attempt_at: $retryAt, last_error: $error }
+			_inc: { attempts: 1 }
+		) {
+			id
+		}
+	}`)
+	req.Var("id", id)
+	req.Var("retryAt", retryAt)
+	req.Var("error", cause.Error())
+	return graphql.GetClient().Run(ctx, req, nil)
+}
+
+// Park takes an event the relay can never publish out of the outbox for
+// good, keeping the reason in last_error.
+func (Store) Park(ctx context.Context, id string, cause error) error {
+	req := newRequest(`
+	mutation ParkOutboxEvent($id: uuid!, $now: timestamptz!, $error: String!) {
+		update_outbox_events_by_pk(
+			pk_columns: { id: $id }
+			_set: { parked_at: $now, last_error: $error }
+		) {
+			id
+		}
+	}`)
+	req.Var("id", id)
+	req.Var("now", time.Now())
+	req.Var("error", cause.Error())
+	return graphql.GetClient().Run(ctx, req, nil)
+}
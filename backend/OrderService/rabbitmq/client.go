@@ -0,0 +1,12 @@
+package rabbitmq
+
+import "messaging"
+
+// Client is the service's connection to RabbitMQ, opened by Connect.
+var Client *messaging.Client
+
+// Connect starts connecting to the broker named by RABBITMQ_URL; publishes
+// wait for the connection and consumers subscribe once it is up.
+func Connect() {
+	Client = messaging.New("order-service", messaging.URL())
+}
@@ -0,0 +1,136 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"messaging"
+	"messaging/contracts"
+
+	"orderservice/orders"
+)
+
+type StockShortage struct {
+	VariantID int `json:"variant_id"`
+	Requested int `json:"requested"`
+	Available int `json:"available"`
+}
+
+// StockRejectedMessage is published by InventoryService when an order could
+// not be fulfilled or was not paid before its stock hold expired; no stock
+// was taken for it.
+type StockRejectedMessage struct {
+	OrderID int             `json:"order_id"`
+	Reason  string          `json:"reason"`
+	Items   []StockShortage `json:"items"`
+}
+
+// StartInventoryConsumer subscribes to the stock outcomes InventoryService
+// reports.
+func StartInventoryConsumer() {
+	Client.Subscribe("orders.stock.rejected", []string{contracts.StockRejected}, 1, handleStockRejected)
+	Client.Subscribe("orders.stock.backordered", []string{contracts.StockBackordered}, 1, handleOrderBackordered)
+	Client.Subscribe("orders.stock.backorder_fulfilled", []string{contracts.StockBackorderFulfilled}, 1, handleBackorderFulfilled)
+}
+
+// OrderBackorderedMessage is published by InventoryService when lines of an
+// order were sold as preorders or backorders.
+type OrderBackorderedMessage struct {
+	OrderID int                      `json:"order_id"`
+	Items   []orders.BackorderedItem `json:"items"`
+}
+
+// BackorderFulfilledMessage is published by InventoryService once stock
+// for a held-back line has arrived.
+type BackorderFulfilledMessage struct {
+	OrderID   int `json:"order_id"`
+	VariantID int `json:"variant_id"`
+}
+
+func handleStockRejected(d amqp.Delivery) error {
+	var msg StockRejectedMessage
+	if _, err := messaging.Decode(d, &msg); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	items, err := orders.Items(ctx, msg.OrderID)
+	if err != nil {
+		return fmt.Errorf("load items of order %d: %w", msg.OrderID, err)
+	}
+
+	cancelled, err := orders.CancelPending(ctx, msg.OrderID, stockRejectionReason(msg, items))
+	if err != nil {
+		return fmt.Errorf("cancel order %d: %w", msg.OrderID, err)
+	}
+	if cancelled {
+		log.Printf("🚫 Order %d cancelled: %s", msg.OrderID, msg.Reason)
+	} else {
+		log.Printf("↩️ Order %d is no longer pending, leaving it as is", msg.OrderID)
+	}
+	return nil
+}
+
+// stockRejectionReason names each short item the way the buyer saw it in
+// their cart, or says the order was not paid before its stock hold
+// expired.
+func stockRejectionReason(msg StockRejectedMessage, items []orders.Item) string {
+	if msg.Reason == "hold_expired" {
+		return "This order was not paid in time, so its items were released."
+	}
+
+	names := map[int]string{}
+	for _, item := range items {
+		name := item.ProductName
+		if item.VariantName != "" {
+			name += " (" + item.VariantName + ")"
+		}
+		names[item.VariantID] = name
+	}
+
+	var parts []string
+	for _, s := range msg.Items {
+		name, ok := names[s.VariantID]
+		if !ok {
+			name = fmt.Sprintf("variant %d", s.VariantID)
+		}
+		if s.Available == 0 {
+			parts = append(parts, name+" is out of stock")
+		} else {
+			parts = append(parts, fmt.Sprintf("%s has only %d left (you ordered %d)", name, s.Available, s.Requested))
+		}
+	}
+	if len(parts) == 0 {
+		return "Some items in this order are no longer in stock."
+	}
+	return "Not enough stock: " + strings.Join(parts, "; ") + "."
+}
+
+func handleOrderBackordered(d amqp.Delivery) error {
+	var msg OrderBackorderedMessage
+	if _, err := messaging.Decode(d, &msg); err != nil {
+		return err
+	}
+
+	if err := orders.MarkAwaitingStock(context.Background(), msg.OrderID, msg.Items); err != nil {
+		return fmt.Errorf("hold back lines of order %d: %w", msg.OrderID, err)
+	}
+	log.Printf("⏳ Order %d has %d line(s) awaiting stock", msg.OrderID, len(msg.Items))
+	return nil
+}
+
+func handleBackorderFulfilled(d amqp.Delivery) error {
+	var msg BackorderFulfilledMessage
+	if _, err := messaging.Decode(d, &msg); err != nil {
+		return err
+	}
+
+	if err := orders.MarkStockReceived(context.Background(), msg.OrderID, msg.VariantID); err != nil {
+		return fmt.Errorf("release line of order %d: %w", msg.OrderID, err)
+	}
+	log.Printf("📦 Order %d: stock for variant %d received", msg.OrderID, msg.VariantID)
+	return nil
+}
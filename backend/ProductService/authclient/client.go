@@ -0,0 +1,78 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// ErrShopNotFound is returned by GetShop for unknown or inactive sellers.
+var ErrShopNotFound = errors.New("shop not found")
+
+type Shop struct {
+	Username        string `json:"username"`
+	ShopName        string `json:"shop_name"`
+	ProfileImageURL string `json:"profile_image_url"`
+}
+
+func baseURL() string {
+	if url := os.Getenv("AUTH_SERVICE_URL"); url != "" {
+		return url
+	}
+	return "http://auth-service:8000"
+}
+
+// ListShops fetches the public profile of every seller from AuthService.
+func ListShops(ctx context.Context) ([]Shop, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL()+"/shops", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service responded with status %d", resp.StatusCode)
+	}
+
+	var shops []Shop
+	if err := json.NewDecoder(resp.Body).Decode(&shops); err != nil {
+		return nil, err
+	}
+	return shops, nil
+}
+
+// GetShop fetches the public profile of a single seller from AuthService.
+func GetShop(ctx context.Context, username string) (*Shop, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL()+"/shops/"+url.PathEscape(username), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrShopNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service responded with status %d", resp.StatusCode)
+	}
+
+	var shop Shop
+	if err := json.NewDecoder(resp.Body).Decode(&shop); err != nil {
+		return nil, err
+	}
+	return &shop, nil
+}
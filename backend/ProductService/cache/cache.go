@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"ProductService/redis"
+)
+
+// BypassHeader skips the cache read and forces a reload from Hasura when set
+// to "1" or "true". The fresh value is still written back.
+const BypassHeader = "X-Cache-Bypass"
+
+const (
+	ProductTTL    = 10 * time.Minute
+	CatalogTTL    = 2 * time.Minute
+	CategoriesTTL = 30 * time.Minute
+
+	lockTTL      = 5 * time.Second
+	lockWait     = 2 * time.Second
+	lockPollStep = 50 * time.Millisecond
+
+	catalogGenerationKey = "cache:catalog:generation"
+	categoriesKey        = "cache:categories"
+	categoryTreeKey      = "cache:categories:tree"
+)
+
+// Status values reported in the X-Cache response header.
+const (
+	StatusHit    = "HIT"
+	StatusMiss   = "MISS"
+	StatusBypass = "BYPASS"
+)
+
+var ctx = context.Background()
+
+var stats struct {
+	hits          uint64
+	misses        uint64
+	bypasses      uint64
+	errors        uint64
+	invalidations uint64
+}
+
+type Stats struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	Bypasses      uint64  `json:"bypasses"`
+	Errors        uint64  `json:"errors"`
+	Invalidations uint64  `json:"invalidations"`
+	HitRatio      float64 `json:"hit_ratio"`
+}
+
+// GetStats returns a snapshot of the cache counters.
+func GetStats() Stats {
+	s := Stats{
+		Hits:          atomic.LoadUint64(&stats.hits),
+		Misses:        atomic.LoadUint64(&stats.misses),
+		Bypasses:      atomic.LoadUint64(&stats.bypasses),
+		Errors:        atomic.LoadUint64(&stats.errors),
+		Invalidations: atomic.LoadUint64(&stats.invalidations),
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRatio = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+func ProductKey(id int) string {
+	return fmt.Sprintf("cache:product:%d", id)
+}
+
+// CatalogKey scopes a catalog page to the current catalog generation, so a
+// single INCR invalidates every cached page at once.
+func CatalogKey(query string) string {
+	gen, err := redis.Client.Get(ctx, catalogGenerationKey).Int64()
+	if err != nil && err != goredis.Nil {
+		atomic.AddUint64(&stats.errors, 1)
+	}
+	return fmt.Sprintf("cache:catalog:%d:%s", gen, query)
+}
+
+func CategoriesKey() string {
+	return categoriesKey
+}
+
+func CategoryTreeKey() string {
+	return categoryTreeKey
+}
+
+// inflight deduplicates concurrent loads of the same key within this process.
+var (
+	inflightMu sync.Mutex
+	inflight   = map[string]*call{}
+)
+
+type call struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+// Fetch returns the value stored under key, calling load on a miss and
+// caching its result for ttl. Concurrent misses for the same key share a
+// single load in-process and wait on a Redis lock across instances.
+func Fetch(key string, ttl time.Duration, bypass bool, load func() ([]byte, error)) ([]byte, string, error) {
+	if bypass {
+		atomic.AddUint64(&stats.bypasses, 1)
+		val, err := load()
+		if err == nil {
+			store(key, val, ttl)
+		}
+		return val, StatusBypass, err
+	}
+
+	if val, ok := lookup(key); ok {
+		atomic.AddUint64(&stats.hits, 1)
+		return val, StatusHit, nil
+	}
+	atomic.AddUint64(&stats.misses, 1)
+
+	inflightMu.Lock()
+	if c, ok := inflight[key]; ok {
+		inflightMu.Unlock()
+		c.wg.Wait()
+		return c.val, StatusMiss, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	inflight[key] = c
+	inflightMu.Unlock()
+
+	c.val, c.err = loadWithLock(key, ttl, load)
+	c.wg.Done()
+
+	inflightMu.Lock()
+	delete(inflight, key)
+	inflightMu.Unlock()
+
+	return c.val, StatusMiss, c.err
+}
+
+// loadWithLock makes sure only one instance reloads a key; the others poll
+// the cache briefly and fall back to loading themselves if the lock holder
+// is too slow.
+func loadWithLock(key string, ttl time.Duration, load func() ([]byte, error)) ([]byte, error) {
+	lockKey := "lock:" + key
+	acquired, err := redis.Client.SetNX(ctx, lockKey, 1, lockTTL).Result()
+	if err != nil {
+		atomic.AddUint64(&stats.errors, 1)
+	}
+
+	if err == nil && !acquired {
+		deadline := time.Now().Add(lockWait)
+		for time.Now().Before(deadline) {
+			time.Sleep(lockPollStep)
+			if val, ok := lookup(key); ok {
+				return val, nil
+			}
+		}
+	}
+
+	val, loadErr := load()
+	if loadErr == nil {
+		store(key, val, ttl)
+	}
+	if acquired {
+		redis.Client.Del(ctx, lockKey)
+	}
+	return val, loadErr
+}
+
+func lookup(key string) ([]byte, bool) {
+	val, err := redis.Client.Get(ctx, key).Bytes()
+	if err == goredis.Nil {
+		return nil, false
+	}
+	if err != nil {
+		atomic.AddUint64(&stats.errors, 1)
+		log.Printf("⚠️ Cache read failed for %s: %v", key, err)
+		return nil, false
+	}
+	return val, true
+}
+
+func store(key string, val []byte, ttl time.Duration) {
+	if err := redis.Client.Set(ctx, key, val, ttl).Err(); err != nil {
+		atomic.AddUint64(&stats.errors, 1)
+		log.Printf("⚠️ Cache write failed for %s: %v", key, err)
+	}
+}
+
+// InvalidateProduct drops the cached details of one product together with
+// every catalog page and the category list, since any of them may include it.
+func InvalidateProduct(productID int) {
+	atomic.AddUint64(&stats.invalidations, 1)
+
+	keys := []string{categoriesKey, categoryTreeKey}
+	if productID > 0 {
+		keys = append(keys, ProductKey(productID))
+	}
+
+	pipe := redis.Client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.Incr(ctx, catalogGenerationKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		atomic.AddUint64(&stats.errors, 1)
+		log.Printf("⚠️ Cache invalidation failed for product %d: %v", productID, err)
+	}
+}
+
+// InvalidateCategories drops the category list and tree, and every catalog
+// page since category filters resolve through the taxonomy.
+func InvalidateCategories() {
+	InvalidateProduct(0)
+}
+
+// InvalidateProductDetails drops only the cached details of one product,
+// for changes catalog pages and categories do not show.
+func InvalidateProductDetails(productID int) {
+	atomic.AddUint64(&stats.invalidations, 1)
+
+	if err := redis.Client.Del(ctx, ProductKey(productID)).Err(); err != nil {
+		atomic.AddUint64(&stats.errors, 1)
+		log.Printf("⚠️ Cache invalidation failed for product %d: %v", productID, err)
+	}
+}
+
+// HandleInventoryEvent invalidates the entries affected by a pub/sub event.
+// Stock moves with every sale, hold and restock, so those events leave the
+// catalog pages to expire on their own.
+func HandleInventoryEvent(event redis.InventoryEvent) {
+	log.Printf("🧹 Invalidating cache for product %d (%s)", event.ProductID, event.Channel)
+	if stockOnly(event) {
+		InvalidateProductDetails(event.ProductID)
+		return
+	}
+	InvalidateProduct(event.ProductID)
+}
+
+// stockOnly reports whether an event changed nothing but a variant's stock
+// or availability.
+func stockOnly(event redis.InventoryEvent) bool {
+	switch event.Type {
+	case "stock_changed", "availability_changed":
+		return !event.PriceChanged
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"testing"
+
+	"ProductService/redis"
+)
+
+func TestStockOnly(t *testing.T) {
+	tests := []struct {
+		event redis.InventoryEvent
+		want  bool
+	}{
+		{redis.InventoryEvent{Type: "stock_changed"}, true},
+		{redis.InventoryEvent{Type: "availability_changed"}, true},
+		{redis.InventoryEvent{Type: "stock_changed", PriceChanged: true}, false},
+		{redis.InventoryEvent{Type: "variant_updated"}, false},
+		{redis.InventoryEvent{Type: "product_updated"}, false},
+		{redis.InventoryEvent{Type: "product_created"}, false},
+	}
+	for _, tt := range tests {
+		if got := stockOnly(tt.event); got != tt.want {
+			t.Errorf("stockOnly(%+v) = %v, want %v", tt.event, got, tt.want)
+		}
+	}
+}
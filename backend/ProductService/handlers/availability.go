@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"ProductService/db"
+)
+
+type AvailabilityCell struct {
+	Size          string  `json:"size"`
+	Color         string  `json:"color"`
+	VariantID     int     `json:"variant_id"`
+	StockQuantity int     `json:"stock_quantity"`
+	Reserved      int     `json:"reserved"`
+	ToSell        int     `json:"available_to_sell"`
+	Available     bool    `json:"available"`
+	Price         float64 `json:"price"`
+}
+
+type Availability struct {
+	ProductID int                `json:"product_id"`
+	Sizes     []string           `json:"sizes"`
+	Colors    []string           `json:"colors"`
+	Matrix    []AvailabilityCell `json:"matrix"`
+}
+
+// GetProductAvailability handles GET /products/{id}/availability and returns
+// one cell per size × color combination that exists as a variant. Stock is
+// read live rather than from the product cache so sold-out combinations can
+// be greyed out immediately. A combination is available when stock is left
+// after active checkout reservations.
+func GetProductAvailability(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT COALESCE(v.size, ''), COALESCE(v.color, ''), v.id, v.stock_quantity,
+		       a.reserved, a.available_to_sell, COALESCE(v.price_override, p.base_price)::float8
+		FROM products p
+		JOIN product_variants v ON v.product_id = p.id
+		JOIN variant_availability a ON a.variant_id = v.id
+		WHERE p.id = $1
+		ORDER BY v.id`, id)
+	if err != nil {
+		log.Println("Failed to fetch availability:", err)
+		http.Error(w, "Failed to fetch availability", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	resp := Availability{ProductID: id, Sizes: []string{}, Colors: []string{}, Matrix: []AvailabilityCell{}}
+	seenSize := map[string]bool{}
+	seenColor := map[string]bool{}
+	for rows.Next() {
+		var cell AvailabilityCell
+		if err := rows.Scan(&cell.Size, &cell.Color, &cell.VariantID, &cell.StockQuantity,
+			&cell.Reserved, &cell.ToSell, &cell.Price); err != nil {
+			log.Println("Failed to scan availability:", err)
+			http.Error(w, "Failed to fetch availability", http.StatusInternalServerError)
+			return
+		}
+		cell.Available = cell.ToSell > 0
+		resp.Matrix = append(resp.Matrix, cell)
+
+		// Sizes and colors keep the order in which the seller added them.
+		if !seenSize[cell.Size] {
+			seenSize[cell.Size] = true
+			resp.Sizes = append(resp.Sizes, cell.Size)
+		}
+		if !seenColor[cell.Color] {
+			seenColor[cell.Color] = true
+			resp.Colors = append(resp.Colors, cell.Color)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("Failed to read availability:", err)
+		http.Error(w, "Failed to fetch availability", http.StatusInternalServerError)
+		return
+	}
+
+	if len(resp.Matrix) == 0 {
+		var exists bool
+		err := db.Pool.QueryRow(r.Context(), `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, id).Scan(&exists)
+		if err != nil {
+			log.Println("Failed to check product:", err)
+			http.Error(w, "Failed to fetch availability", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"ProductService/cache"
+	"ProductService/db"
+)
+
+type Category struct {
+	ID           int         `json:"id"`
+	ParentID     *int        `json:"parent_id"`
+	Name         string      `json:"name"`
+	Slug         string      `json:"slug"`
+	DisplayOrder int         `json:"display_order"`
+	ProductCount int         `json:"product_count"`
+	Children     []*Category `json:"children"`
+}
+
+type CategoryInput struct {
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	ParentID     *int   `json:"parent_id"`
+	DisplayOrder int    `json:"display_order"`
+}
+
+// categorySubtreeNames resolves a category name or slug to the lower-cased
+// names of that category and all of its descendants.
+func categorySubtreeNames(ctx context.Context, category string) (map[string]bool, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT LOWER(c.name)
+		FROM categories root
+		CROSS JOIN LATERAL category_subtree(root.id) AS sub(id)
+		JOIN categories c ON c.id = sub.id
+		WHERE root.slug = category_slug($1) OR LOWER(root.name) = LOWER($1)`, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
+
+// GetCategoryTree returns the category hierarchy with the number of listed
+// products in each node, descendants included.
+func GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	body, status, err := cache.Fetch(cache.CategoryTreeKey(), cache.CategoriesTTL, cacheBypassed(r), func() ([]byte, error) {
+		tree, err := loadCategoryTree(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]interface{}{
+			"categories": tree,
+		})
+	})
+	if err != nil {
+		log.Println("Failed to load category tree:", err)
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+
+	writeCachedJSON(w, body, status)
+}
+
+func loadCategoryTree(ctx context.Context) ([]*Category, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT c.id, c.parent_id, c.name, c.slug, c.display_order,
+		       (SELECT COUNT(*)
+		        FROM products p
+		        WHERE p.listed AND p.category_id IN (SELECT category_subtree(c.id)))
+		FROM categories c
+		ORDER BY c.display_order, c.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []*Category
+	byID := make(map[int]*Category)
+	for rows.Next() {
+		c := &Category{Children: []*Category{}}
+		var count int64
+		if err := rows.Scan(&c.ID, &c.ParentID, &c.Name, &c.Slug, &c.DisplayOrder, &count); err != nil {
+			return nil, err
+		}
+		c.ProductCount = int(count)
+		all = append(all, c)
+		byID[c.ID] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roots := []*Category{}
+	for _, c := range all {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+	return roots, nil
+}
+
+func decodeCategoryInput(r *http.Request) (*CategoryInput, error) {
+	var input CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errors.New("Invalid JSON")
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, errors.New("Category name is required")
+	}
+	if input.Slug == "" {
+		input.Slug = input.Name
+	}
+	return &input, nil
+}
+
+func writeCategoryError(w http.ResponseWriter, err error, action string) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			http.Error(w, "A category with this slug already exists", http.StatusConflict)
+			return
+		case "23503":
+			http.Error(w, "Parent category does not exist", http.StatusBadRequest)
+			return
+		}
+	}
+	log.Printf("❌ Failed to %s category: %v", action, err)
+	http.Error(w, "Failed to "+action+" category", http.StatusInternalServerError)
+}
+
+// CreateCategory handles POST /admin/categories.
+func CreateCategory(w http.ResponseWriter, r *http.Request) {
+	input, err := decodeCategoryInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var c Category
+	err = db.Pool.QueryRow(r.Context(), `
+		INSERT INTO categories (name, slug, parent_id, display_order)
+		VALUES ($1, category_slug($2), $3, $4)
+		RETURNING id, parent_id, name, slug, display_order`,
+		input.Name, input.Slug, input.ParentID, input.DisplayOrder,
+	).Scan(&c.ID, &c.ParentID, &c.Name, &c.Slug, &c.DisplayOrder)
+	if err != nil {
+		writeCategoryError(w, err, "create")
+		return
+	}
+
+	cache.InvalidateCategories()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// UpdateCategory handles PUT /admin/categories/{id}.
+func UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	input, err := decodeCategoryInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if input.ParentID != nil {
+		// A category cannot move below itself or one of its descendants.
+		var cycle bool
+		err := db.Pool.QueryRow(r.Context(),
+			`SELECT $2 IN (SELECT category_subtree($1))`, id, *input.ParentID).Scan(&cycle)
+		if err != nil {
+			writeCategoryError(w, err, "update")
+			return
+		}
+		if cycle {
+			http.Error(w, "A category cannot be its own ancestor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var c Category
+	err = db.Pool.QueryRow(r.Context(), `
+		UPDATE categories
+		SET name = $2, slug = category_slug($3), parent_id = $4, display_order = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, parent_id, name, slug, display_order`,
+		id, input.Name, input.Slug, input.ParentID, input.DisplayOrder,
+	).Scan(&c.ID, &c.ParentID, &c.Name, &c.Slug, &c.DisplayOrder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeCategoryError(w, err, "update")
+		return
+	}
+
+	cache.InvalidateCategories()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// DeleteCategory handles DELETE /admin/categories/{id}. Products in the
+// category move to its parent, or to Uncategorized for a top-level category.
+func DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		writeCategoryError(w, err, "delete")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var slug string
+	var parentID *int
+	var children int64
+	err = tx.QueryRow(ctx, `
+		SELECT slug, parent_id, (SELECT COUNT(*) FROM categories WHERE parent_id = $1)
+		FROM categories
+		WHERE id = $1
+		FOR UPDATE`, id).Scan(&slug, &parentID, &children)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeCategoryError(w, err, "delete")
+		return
+	}
+	if slug == "uncategorized" {
+		http.Error(w, "The Uncategorized category cannot be deleted", http.StatusBadRequest)
+		return
+	}
+	if children > 0 {
+		http.Error(w, "Move or delete the subcategories first", http.StatusConflict)
+		return
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE products
+		SET category_id = COALESCE($2, (SELECT id FROM categories WHERE slug = 'uncategorized'))
+		WHERE category_id = $1`, id, parentID)
+	if err == nil {
+		_, err = tx.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		writeCategoryError(w, err, "delete")
+		return
+	}
+
+	cache.InvalidateCategories()
+
+	w.WriteHeader(http.StatusNoContent)
+}
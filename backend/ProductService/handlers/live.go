@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ProductService/live"
+)
+
+const (
+	heartbeatInterval   = 15 * time.Second
+	maxLiveSubscription = 50
+)
+
+// LiveProductUpdates handles GET /live/products?ids=1,2,3 as a Server-Sent
+// Events stream of "stock" and "price" events for the given products.
+func LiveProductUpdates(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var ids []int
+	for _, part := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 || len(ids) > maxLiveSubscription {
+		http.Error(w, fmt.Sprintf("Provide between 1 and %d product IDs", maxLiveSubscription), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	client := live.Subscribe(ids)
+	defer live.Unsubscribe(client)
+	log.Printf("📡 Live client subscribed to products %v", ids)
+
+	fmt.Fprintf(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.Dropped:
+			fmt.Fprintf(w, "event: dropped\ndata: {\"reason\":\"slow consumer\"}\n\n")
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case msg := <-client.Messages():
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Event, msg.Data)
+			flusher.Flush()
+		}
+	}
+}
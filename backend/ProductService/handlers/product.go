@@ -1,96 +1,130 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
-)
 
-type Product struct {
-	ID             int              `json:"id"`
-	Name           string           `json:"name"`
-	Description    string           `json:"description"`
-	BasePrice      float64          `json:"base_price"`
-	Image          string           `json:"image"`
-	Category       string           `json:"category"`
-	SKU            string           `json:"sku"`
-	Listed         bool             `json:"listed"`
-	SellerID       int              `json:"seller_id"`
-	SellerUsername string           `json:"seller_username"`
-	CreatedAt      string           `json:"created_at"`
-	UpdatedAt      string           `json:"updated_at"`
-	ProductVariants []ProductVariant `json:"product_variants,omitempty"` // Only used for Product Details
-}
+	"ProductService/cache"
+	"ProductService/db"
+	"ProductService/models"
+)
 
-type ProductVariant struct {
-	ID            int     `json:"id"`
-	ProductID     int     `json:"product_id"`
-	VariantName   string  `json:"variant_name"`
-	Size          string  `json:"size"`
-	Color         string  `json:"color"`
-	SKU           string  `json:"sku"`
-	StockQuantity int     `json:"stock_quantity"`
-	Image         string  `json:"image"`
-	CreatedAt     string  `json:"created_at"`
-	UpdatedAt     string  `json:"updated_at"`
-}
+const inventoryRESTURL = "http://hasura-inventory:8080/api/rest"
 
-func GetProducts(w http.ResponseWriter, r *http.Request) {
-	log.Println("Incoming /products request with query params:", r.URL.RawQuery)
+// errProductNotFound is returned by the product loader so a 404 is never cached.
+var errProductNotFound = errors.New("product not found")
 
-	// ✅ Updated to fetch from the correct Hasura Inventory service (catalog: no variants)
-	req, err := http.NewRequest("GET", "http://hasura-inventory:8080/api/rest/products", nil)
+// fetchInventory calls a Hasura Inventory REST endpoint and returns the raw body.
+func fetchInventory(path string) ([]byte, int, error) {
+	req, err := http.NewRequest("GET", inventoryRESTURL+path, nil)
 	if err != nil {
-		log.Println("Failed to create request:", err)
-		http.Error(w, "Failed to create request", http.StatusInternalServerError)
-		return
+		return nil, 0, err
 	}
 	req.Header.Set("x-hasura-admin-secret", "password")
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Println("Failed to fetch from InventoryService:", err)
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	return bodyBytes, resp.StatusCode, err
+}
+
+func cacheBypassed(r *http.Request) bool {
+	v := strings.ToLower(r.Header.Get(cache.BypassHeader))
+	return v == "1" || v == "true"
+}
+
+func writeCachedJSON(w http.ResponseWriter, body []byte, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", status)
+	w.Write(body)
+}
+
+func GetProducts(w http.ResponseWriter, r *http.Request) {
+	log.Println("Incoming /products request with query params:", r.URL.RawQuery)
+
+	query := r.URL.Query()
+	key := cache.CatalogKey(query.Encode())
+
+	body, status, err := cache.Fetch(key, cache.CatalogTTL, cacheBypassed(r), func() ([]byte, error) {
+		filtered, err := loadProducts(query)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(filtered)
+	})
+	if err != nil {
+		log.Println("Failed to load products:", err)
 		http.Error(w, "Failed to fetch from InventoryService", http.StatusInternalServerError)
 		return
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
+	writeCachedJSON(w, body, status)
+}
+
+func loadProducts(query url.Values) ([]models.Product, error) {
+	// ✅ Updated to fetch from the correct Hasura Inventory service (catalog: no variants)
+	bodyBytes, statusCode, err := fetchInventory("/products")
+	if err != nil {
+		return nil, err
+	}
+	// An error body must not be cached as an empty catalog.
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("inventory service responded with status %d", statusCode)
+	}
 	log.Println("InventoryService (catalog) response body:", string(bodyBytes))
 
 	var result struct {
-		Products []Product `json:"products"`
+		Products []models.Product `json:"products"`
 	}
 	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		log.Println("Failed to decode InventoryService response:", err)
-		http.Error(w, "Failed to decode InventoryService response", http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	if err := attachRatings(context.Background(), result.Products); err != nil {
+		return nil, err
 	}
 
 	// Apply query filters
-	queryName := strings.ToLower(r.URL.Query().Get("name"))
-	queryCategory := strings.ToLower(r.URL.Query().Get("category"))
-	queryListed := r.URL.Query().Get("listed")
-	querySKU := strings.ToLower(r.URL.Query().Get("sku"))
-	querySellerUsername := strings.ToLower(r.URL.Query().Get("seller_username"))
-
-	priceMinStr := r.URL.Query().Get("price_min")
-	priceMaxStr := r.URL.Query().Get("price_max")
+	queryName := strings.ToLower(query.Get("name"))
+	queryCategory := strings.ToLower(query.Get("category"))
+	var categoryNames map[string]bool
+	if queryCategory != "" {
+		// Filtering by a category includes every category below it.
+		categoryNames, err = categorySubtreeNames(context.Background(), queryCategory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	queryListed := query.Get("listed")
+	querySKU := strings.ToLower(query.Get("sku"))
+	querySellerUsername := strings.ToLower(query.Get("seller_username"))
+
+	priceMinStr := query.Get("price_min")
+	priceMaxStr := query.Get("price_max")
 	priceMin, _ := strconv.ParseFloat(priceMinStr, 64)
 	priceMax, _ := strconv.ParseFloat(priceMaxStr, 64)
 
-	var filtered []Product
+	filtered := []models.Product{}
 	for _, p := range result.Products {
 		match := true
 
 		if queryName != "" && !strings.Contains(strings.ToLower(p.Name), queryName) {
 			match = false
 		}
-		if queryCategory != "" && strings.ToLower(p.Category) != queryCategory {
+		if queryCategory != "" && !categoryNames[strings.ToLower(p.Category)] && strings.ToLower(p.Category) != queryCategory {
 			match = false
 		}
 		if queryListed != "" {
@@ -116,8 +150,71 @@ func GetProducts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(filtered)
+	sortProducts(filtered, query.Get("sort"))
+	return filtered, nil
+}
+
+// attachRatings copies the rating aggregates kept in inventorydb onto the
+// catalog entries returned by Hasura.
+func attachRatings(ctx context.Context, products []models.Product) error {
+	rows, err := db.Pool.Query(ctx, `SELECT id, rating_average, rating_count FROM products`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type rating struct {
+		average float64
+		count   int
+	}
+	ratings := make(map[int]rating)
+	for rows.Next() {
+		var id int
+		var r rating
+		if err := rows.Scan(&id, &r.average, &r.count); err != nil {
+			return err
+		}
+		ratings[id] = r
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range products {
+		r := ratings[products[i].ID]
+		products[i].RatingAverage = r.average
+		products[i].RatingCount = r.count
+	}
+	return nil
+}
+
+// sortProducts orders the catalog by the sort query param: rating, reviews,
+// price_asc, price_desc or newest. Anything else keeps the Hasura order.
+func sortProducts(products []models.Product, by string) {
+	var less func(a, b models.Product) bool
+	switch by {
+	case "rating":
+		less = func(a, b models.Product) bool {
+			if a.RatingAverage != b.RatingAverage {
+				return a.RatingAverage > b.RatingAverage
+			}
+			return a.RatingCount > b.RatingCount
+		}
+	case "reviews":
+		less = func(a, b models.Product) bool { return a.RatingCount > b.RatingCount }
+	case "price_asc":
+		less = func(a, b models.Product) bool { return a.BasePrice < b.BasePrice }
+	case "price_desc":
+		less = func(a, b models.Product) bool { return a.BasePrice > b.BasePrice }
+	case "newest":
+		less = func(a, b models.Product) bool { return a.CreatedAt > b.CreatedAt }
+	default:
+		return
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		return less(products[i], products[j])
+	})
 }
 
 func GetProductByID(w http.ResponseWriter, r *http.Request) {
@@ -128,94 +225,165 @@ func GetProductByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url := "http://hasura-inventory:8080/api/rest/products/" + strconv.Itoa(id) + "?id=" + strconv.Itoa(id)
-	log.Println("Fetching product details from:", url)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Println("Failed to create request:", err)
-		http.Error(w, "Failed to create request", http.StatusInternalServerError)
+	body, status, err := cache.Fetch(cache.ProductKey(id), cache.ProductTTL, cacheBypassed(r), func() ([]byte, error) {
+		product, err := loadProduct(id)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(product)
+	})
+	if errors.Is(err, errProductNotFound) {
+		http.Error(w, "Product not found", http.StatusNotFound)
 		return
 	}
-	req.Header.Set("x-hasura-admin-secret", "password")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
 	if err != nil {
-		log.Println("Failed to fetch from InventoryService:", err)
+		log.Println("Failed to load product details:", err)
 		http.Error(w, "Failed to fetch from InventoryService", http.StatusInternalServerError)
 		return
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
+	writeCachedJSON(w, body, status)
+}
+
+func loadProduct(id int) (*models.Product, error) {
+	path := "/products/" + strconv.Itoa(id) + "?id=" + strconv.Itoa(id)
+	log.Println("Fetching product details from:", inventoryRESTURL+path)
+
+	bodyBytes, statusCode, err := fetchInventory(path)
+	if err != nil {
+		return nil, err
+	}
 	log.Println("InventoryService (details) response body:", string(bodyBytes))
 
-	if resp.StatusCode == http.StatusNotFound {
-		http.Error(w, "Product not found", http.StatusNotFound)
-		return
+	if statusCode == http.StatusNotFound {
+		return nil, errProductNotFound
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("inventory service responded with status %d", statusCode)
 	}
 
 	// ✅ Decode full product details (includes product_variants)
 	var product struct {
-		Product Product `json:"products_by_pk"`
+		Product *models.Product `json:"products_by_pk"`
 	}
 	if err := json.Unmarshal(bodyBytes, &product); err != nil {
-		log.Println("Failed to decode InventoryService response:", err)
-		http.Error(w, "Failed to decode InventoryService response", http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	if product.Product == nil {
+		return nil, errProductNotFound
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product.Product)
-}
+	p := product.Product
+	err = db.Pool.QueryRow(context.Background(), `
+		SELECT rating_average, rating_count, rating_breakdown
+		FROM products
+		WHERE id = $1`, id).Scan(&p.RatingAverage, &p.RatingCount, &p.RatingBreakdown)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachVariantDetails(context.Background(), p); err != nil {
+		return nil, err
+	}
 
-func GetCategories(w http.ResponseWriter, r *http.Request) {
-	log.Println("Fetching categories...")
+	return p, nil
+}
 
-	req, err := http.NewRequest("GET", "http://hasura-inventory:8080/api/rest/categories", nil)
+// attachVariantDetails fills in each variant's override, compare-at and
+// effective price, its available-to-sell and how it is fulfilled from
+// inventorydb.
+func attachVariantDetails(ctx context.Context, p *models.Product) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT v.id, v.price_override::float8, v.compare_at_price::float8, a.available_to_sell,
+		       v.fulfilment_mode, v.expected_ship_date::text
+		FROM product_variants v
+		JOIN variant_availability a ON a.variant_id = v.id
+		WHERE v.product_id = $1`, p.ID)
 	if err != nil {
-		log.Println("Failed to create request:", err)
-		http.Error(w, "Failed to create request", http.StatusInternalServerError)
-		return
+		return err
 	}
-	req.Header.Set("x-hasura-admin-secret", "password")
+	defer rows.Close()
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	type details struct {
+		override  *float64
+		compareAt *float64
+		available int
+		mode      string
+		shipDate  *string
+	}
+	byID := make(map[int]details)
+	for rows.Next() {
+		var id int
+		var d details
+		if err := rows.Scan(&id, &d.override, &d.compareAt, &d.available, &d.mode, &d.shipDate); err != nil {
+			return err
+		}
+		byID[id] = d
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range p.ProductVariants {
+		v := &p.ProductVariants[i]
+		d := byID[v.ID]
+		v.PriceOverride = d.override
+		v.CompareAtPrice = d.compareAt
+		v.Available = d.available
+		v.FulfilmentMode = d.mode
+		v.ExpectedShipDate = d.shipDate
+		v.Price = p.BasePrice
+		if d.override != nil {
+			v.Price = *d.override
+		}
+	}
+	return nil
+}
+
+func GetCategories(w http.ResponseWriter, r *http.Request) {
+	log.Println("Fetching categories...")
+
+	body, status, err := cache.Fetch(cache.CategoriesKey(), cache.CategoriesTTL, cacheBypassed(r), func() ([]byte, error) {
+		categories, err := loadCategories()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]interface{}{
+			"categories": categories,
+		})
+	})
 	if err != nil {
-		log.Println("Failed to fetch from InventoryService:", err)
+		log.Println("Failed to load categories:", err)
 		http.Error(w, "Failed to fetch from InventoryService", http.StatusInternalServerError)
 		return
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	log.Println("Categories response body:", string(bodyBytes))
-
-	var result struct {
-		Products []struct {
-			Category string `json:"category"`
-		} `json:"products"`
-	}
+	writeCachedJSON(w, body, status)
+}
 
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		log.Println("Failed to decode InventoryService response:", err)
-		http.Error(w, "Failed to decode InventoryService response", http.StatusInternalServerError)
-		return
+func loadCategories() ([]string, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+		SELECT name
+		FROM categories
+		ORDER BY display_order, name`)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	uniqueCategories := []string{}
-	seen := make(map[string]bool)
-	for _, item := range result.Products {
-		if item.Category != "" && !seen[item.Category] {
-			seen[item.Category] = true
-			uniqueCategories = append(uniqueCategories, item.Category)
+	categories := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
 		}
+		categories = append(categories, name)
 	}
 
+	return categories, rows.Err()
+}
+
+// GetCacheStats reports cache hit/miss counters.
+func GetCacheStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"categories": uniqueCategories,
-	})
+	json.NewEncoder(w).Encode(cache.GetStats())
 }
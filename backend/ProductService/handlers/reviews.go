@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"ProductService/db"
+	"ProductService/orderclient"
+)
+
+const (
+	maxReviewLength = 2000
+	maxReviewPhotos = 5
+)
+
+type Review struct {
+	ID              int        `json:"id"`
+	ProductID       int        `json:"product_id"`
+	BuyerUsername   string     `json:"buyer_username"`
+	Rating          int        `json:"rating"`
+	Body            string     `json:"body"`
+	PhotoURLs       []string   `json:"photo_urls"`
+	Status          string     `json:"status,omitempty"`
+	SellerReply     *string    `json:"seller_reply"`
+	SellerRepliedAt *time.Time `json:"seller_replied_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+const reviewColumns = `id, product_id, buyer_username, rating, body, photo_urls, status,
+	seller_reply, seller_replied_at, created_at`
+
+func scanReview(row pgx.Row) (*Review, error) {
+	var rv Review
+	var rating int16
+	err := row.Scan(&rv.ID, &rv.ProductID, &rv.BuyerUsername, &rating, &rv.Body, &rv.PhotoURLs,
+		&rv.Status, &rv.SellerReply, &rv.SellerRepliedAt, &rv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rv.Rating = int(rating)
+	return &rv, nil
+}
+
+func reviewIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetProductReviews handles GET /products/{id}/reviews?page=&page_size=&rating=
+func GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	rating, _ := strconv.Atoi(r.URL.Query().Get("rating"))
+	page, pageSize := parsePage(r)
+
+	rows, err := db.Pool.Query(r.Context(), `
+		SELECT `+reviewColumns+`, COUNT(*) OVER ()
+		FROM product_reviews
+		WHERE product_id = $1 AND status = 'published' AND ($2 = 0 OR rating = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, productID, rating, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Println("Failed to fetch reviews:", err)
+		http.Error(w, "Failed to fetch reviews", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	reviews := []Review{}
+	var total int64
+	for rows.Next() {
+		var rv Review
+		var stars int16
+		if err := rows.Scan(&rv.ID, &rv.ProductID, &rv.BuyerUsername, &stars, &rv.Body, &rv.PhotoURLs,
+			&rv.Status, &rv.SellerReply, &rv.SellerRepliedAt, &rv.CreatedAt, &total); err != nil {
+			log.Println("Failed to scan review:", err)
+			http.Error(w, "Failed to fetch reviews", http.StatusInternalServerError)
+			return
+		}
+		rv.Rating = int(stars)
+		rv.Status = ""
+		reviews = append(reviews, rv)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"reviews":   reviews,
+	})
+}
+
+// CreateReview handles POST /products/{id}/reviews. Only buyers with a
+// delivered order containing the product may review it, once.
+func CreateReview(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Rating    int      `json:"rating"`
+		Body      string   `json:"body"`
+		PhotoURLs []string `json:"photo_urls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Rating < 1 || req.Rating > 5 {
+		http.Error(w, "Rating must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+	if len(req.Body) > maxReviewLength {
+		http.Error(w, "Review text is too long", http.StatusBadRequest)
+		return
+	}
+	if len(req.PhotoURLs) > maxReviewPhotos {
+		http.Error(w, "Too many photos", http.StatusBadRequest)
+		return
+	}
+	for _, photo := range req.PhotoURLs {
+		u, err := url.Parse(photo)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, "Invalid photo URL", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.PhotoURLs == nil {
+		req.PhotoURLs = []string{}
+	}
+
+	orderID, err := orderclient.DeliveredOrderID(r.Context(), user.ID, productID)
+	if err != nil {
+		log.Println("Failed to verify purchase:", err)
+		http.Error(w, "Failed to verify purchase", http.StatusInternalServerError)
+		return
+	}
+	if orderID == 0 {
+		http.Error(w, "Only buyers with a delivered order can review this product", http.StatusForbidden)
+		return
+	}
+
+	review, err := scanReview(db.Pool.QueryRow(r.Context(), `
+		INSERT INTO product_reviews (product_id, order_id, buyer_id, buyer_username, rating, body, photo_urls)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+reviewColumns,
+		productID, orderID, user.ID, user.Username, req.Rating, req.Body, req.PhotoURLs))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			http.Error(w, "You have already reviewed this product", http.StatusConflict)
+			return
+		}
+		log.Println("Failed to create review:", err)
+		http.Error(w, "Failed to create review", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// ReplyToReview handles POST /reviews/{id}/reply for the seller of the
+// reviewed product.
+func ReplyToReview(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	id, ok := reviewIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reply string `json:"reply"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Reply = strings.TrimSpace(req.Reply)
+	if req.Reply == "" || len(req.Reply) > maxReviewLength {
+		http.Error(w, "Reply must be between 1 and 2000 characters", http.StatusBadRequest)
+		return
+	}
+
+	review, err := scanReview(db.Pool.QueryRow(r.Context(), `
+		UPDATE product_reviews rv
+		SET seller_reply = $3, seller_replied_at = NOW(), updated_at = NOW()
+		FROM products p
+		WHERE rv.id = $1 AND p.id = rv.product_id AND p.seller_id = $2
+		RETURNING rv.id, rv.product_id, rv.buyer_username, rv.rating, rv.body, rv.photo_urls, rv.status,
+		          rv.seller_reply, rv.seller_replied_at, rv.created_at`,
+		id, user.ID, req.Reply))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("Failed to reply to review:", err)
+		http.Error(w, "Failed to reply to review", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// ModerateReview handles PUT /admin/reviews/{id} to hide or republish a
+// review. Hidden reviews drop out of the product's rating aggregates.
+func ModerateReview(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Status != "published" && req.Status != "hidden" {
+		http.Error(w, "Status must be published or hidden", http.StatusBadRequest)
+		return
+	}
+
+	review, err := scanReview(db.Pool.QueryRow(r.Context(), `
+		UPDATE product_reviews
+		SET status = $2, moderation_note = NULLIF($3, ''), updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+reviewColumns, id, req.Status, req.Note))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("Failed to moderate review:", err)
+		http.Error(w, "Failed to moderate review", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
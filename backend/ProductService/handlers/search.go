@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+
+	"ProductService/db"
+)
+
+type SearchResult struct {
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	BasePrice      float64 `json:"base_price"`
+	Image          string  `json:"image"`
+	Category       string  `json:"category"`
+	SellerUsername string  `json:"seller_username"`
+	Score          float64 `json:"score"`
+	NameHighlight  string  `json:"name_highlight"` // escaped HTML, matches in <mark>
+	Snippet        string  `json:"snippet"`        // escaped HTML, matches in <mark>
+}
+
+type CategoryFacet struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+type PriceFacet struct {
+	Label string   `json:"label"`
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
+}
+
+type SearchResponse struct {
+	Query    string         `json:"query"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Total    int            `json:"total"`
+	Results  []SearchResult `json:"results"`
+	Facets   struct {
+		Categories  []CategoryFacet `json:"categories"`
+		PriceRanges []PriceFacet    `json:"price_ranges"`
+	} `json:"facets"`
+}
+
+// ts_headline wraps matches in these control characters instead of <mark>,
+// so the seller-supplied text can be escaped before the tags go in.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// highlightHTML escapes a ts_headline result and turns its selectors into
+// <mark> tags; any markup of the seller's own is shown as text.
+func highlightHTML(headline string) string {
+	return strings.NewReplacer(
+		highlightStart, "<mark>",
+		highlightStop, "</mark>",
+	).Replace(html.EscapeString(headline))
+}
+
+// priceBuckets are the upper bounds (PHP) of the price facet ranges; the
+// last range is open-ended.
+var priceBuckets = []float64{500, 1000, 2500, 5000}
+
+// searchMatchesCTE selects listed products matching $1 either through the
+// weighted tsvector or, for typos, through trigram similarity on the name.
+const searchMatchesCTE = `
+	WITH query AS (
+		SELECT websearch_to_tsquery('english', $1) AS tsq
+	),
+	matches AS (
+		SELECT p.id, p.name, p.description, p.base_price, p.image, p.category, p.category_id, p.seller_username,
+		       ts_rank_cd('{0.1, 0.2, 0.4, 1.0}', p.search_vector, query.tsq) +
+		       0.5 * similarity(p.name, $1) AS score
+		FROM products p, query
+		WHERE p.listed
+		  AND (p.search_vector @@ query.tsq OR p.name % $1)
+	)`
+
+const searchFilters = `
+	($2 = '' OR category_id IN (
+		SELECT category_subtree(root.id)
+		FROM categories root
+		WHERE root.slug = category_slug($2) OR LOWER(root.name) = LOWER($2)))
+	AND ($3::numeric IS NULL OR base_price >= $3)
+	AND ($4::numeric IS NULL OR base_price <= $4)`
+
+// SearchProducts handles GET /search?q=&category=&price_min=&price_max=&page=&page_size=
+func SearchProducts(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	priceMin := parseOptionalFloat(r, "price_min")
+	priceMax := parseOptionalFloat(r, "price_max")
+	page, pageSize := parsePage(r)
+
+	resp := SearchResponse{
+		Query:    q,
+		Page:     page,
+		PageSize: pageSize,
+		Results:  []SearchResult{},
+	}
+
+	ctx := r.Context()
+	if err := searchResults(ctx, &resp, q, category, priceMin, priceMax); err != nil {
+		log.Println("Search query failed:", err)
+		http.Error(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+
+	// Each facet ignores its own filter so the UI can show the alternatives.
+	var err error
+	resp.Facets.Categories, err = categoryFacets(ctx, q, priceMin, priceMax)
+	if err == nil {
+		resp.Facets.PriceRanges, err = priceFacets(ctx, q, category)
+	}
+	if err != nil {
+		log.Println("Search facets failed:", err)
+		http.Error(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func searchResults(ctx context.Context, resp *SearchResponse, q, category string, priceMin, priceMax *float64) error {
+	// Headlines are computed for the current page only; ts_headline is expensive.
+	sql := searchMatchesCTE + `
+	SELECT page.id, page.name, page.base_price, page.image, page.category, page.seller_username, page.score,
+	       ts_headline('english', page.name, query.tsq, 'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, HighlightAll=true'),
+	       ts_headline('english', COALESCE(page.description, ''), query.tsq,
+	                   'StartSel=` + highlightStart + `, StopSel=` + highlightStop + `, MaxFragments=2, MaxWords=20, MinWords=5'),
+	       page.total
+	FROM (
+		SELECT *, COUNT(*) OVER () AS total
+		FROM matches
+		WHERE ` + searchFilters + `
+		ORDER BY score DESC, id
+		LIMIT $5 OFFSET $6
+	) page, query
+	ORDER BY page.score DESC, page.id`
+
+	rows, err := db.Pool.Query(ctx, sql, q, category, priceMin, priceMax, resp.PageSize, (resp.Page-1)*resp.PageSize)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res SearchResult
+		var total int64
+		if err := rows.Scan(
+			&res.ID, &res.Name, &res.BasePrice, &res.Image, &res.Category, &res.SellerUsername,
+			&res.Score, &res.NameHighlight, &res.Snippet, &total,
+		); err != nil {
+			return err
+		}
+		res.NameHighlight = highlightHTML(res.NameHighlight)
+		res.Snippet = highlightHTML(res.Snippet)
+		resp.Total = int(total)
+		resp.Results = append(resp.Results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Past the last page the window count is unavailable; count separately.
+	if len(resp.Results) == 0 && resp.Page > 1 {
+		var total int64
+		err := db.Pool.QueryRow(ctx, searchMatchesCTE+`
+			SELECT COUNT(*) FROM matches WHERE `+searchFilters,
+			q, category, priceMin, priceMax).Scan(&total)
+		if err != nil {
+			return err
+		}
+		resp.Total = int(total)
+	}
+
+	return nil
+}
+
+func categoryFacets(ctx context.Context, q string, priceMin, priceMax *float64) ([]CategoryFacet, error) {
+	rows, err := db.Pool.Query(ctx, searchMatchesCTE+`
+		SELECT category, COUNT(*)
+		FROM matches
+		WHERE `+searchFilters+`
+		GROUP BY category
+		ORDER BY COUNT(*) DESC, category`,
+		q, "", priceMin, priceMax)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := []CategoryFacet{}
+	for rows.Next() {
+		var f CategoryFacet
+		var count int64
+		if err := rows.Scan(&f.Category, &count); err != nil {
+			return nil, err
+		}
+		f.Count = int(count)
+		facets = append(facets, f)
+	}
+	return facets, rows.Err()
+}
+
+func priceFacets(ctx context.Context, q, category string) ([]PriceFacet, error) {
+	rows, err := db.Pool.Query(ctx, searchMatchesCTE+`
+		SELECT width_bucket(base_price, $5::numeric[]), COUNT(*)
+		FROM matches
+		WHERE `+searchFilters+`
+		GROUP BY 1`,
+		q, category, nil, nil, priceBuckets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int]int{}
+	for rows.Next() {
+		var bucket int
+		var count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		counts[bucket] = int(count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// width_bucket returns 0 below the first bound and len(bounds) at or
+	// above the last one.
+	facets := make([]PriceFacet, 0, len(priceBuckets)+1)
+	lower := 0.0
+	for i := 0; i <= len(priceBuckets); i++ {
+		f := PriceFacet{Min: lower, Count: counts[i]}
+		if i < len(priceBuckets) {
+			upper := priceBuckets[i]
+			f.Max = &upper
+			f.Label = fmt.Sprintf("%.0f - %.0f", lower, upper)
+			lower = upper
+		} else {
+			f.Label = fmt.Sprintf("%.0f+", lower)
+		}
+		facets = append(facets, f)
+	}
+	return facets, nil
+}
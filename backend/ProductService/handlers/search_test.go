@@ -0,0 +1,20 @@
+package handlers
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"plain text", "plain text"},
+		{"red \x02shirt\x03", "red <mark>shirt</mark>"},
+		{"<script>alert(1)</script> \x02shirt\x03", "&lt;script&gt;alert(1)&lt;/script&gt; <mark>shirt</mark>"},
+		{"\x02<b>\x03 & \"quotes\"", "<mark>&lt;b&gt;</mark> &amp; &#34;quotes&#34;"},
+	}
+	for _, tt := range tests {
+		if got := highlightHTML(tt.headline); got != tt.want {
+			t.Errorf("highlightHTML(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"auth"
+	"github.com/go-chi/chi/v5"
+
+	"ProductService/authclient"
+	"ProductService/cache"
+	"ProductService/db"
+	"ProductService/models"
+)
+
+type ShopRatings struct {
+	Average   float64        `json:"average"`
+	Count     int            `json:"count"`
+	Breakdown map[string]int `json:"breakdown"`
+}
+
+type Storefront struct {
+	Username        string           `json:"username"`
+	ShopName        string           `json:"shop_name"`
+	ProfileImageURL string           `json:"profile_image_url"`
+	FollowerCount   int              `json:"follower_count"`
+	Following       bool             `json:"following"`
+	Ratings         ShopRatings      `json:"ratings"`
+	Categories      []CategoryFacet  `json:"categories"`
+	Page            int              `json:"page"`
+	PageSize        int              `json:"page_size"`
+	Total           int              `json:"total"`
+	Products        []models.Product `json:"products"`
+}
+
+// GetShop handles GET /shops/{username}?page=&page_size=&category=&sort=
+// and returns the seller's profile alongside their listed products.
+func GetShop(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	ctx := r.Context()
+
+	shop, err := authclient.GetShop(ctx, username)
+	if errors.Is(err, authclient.ErrShopNotFound) {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("Failed to fetch shop profile:", err)
+		http.Error(w, "Failed to fetch shop", http.StatusInternalServerError)
+		return
+	}
+
+	// The listing shares its cache entries with GET /products.
+	query := url.Values{}
+	query.Set("seller_username", shop.Username)
+	query.Set("listed", "true")
+	if sortBy := r.URL.Query().Get("sort"); sortBy != "" {
+		query.Set("sort", sortBy)
+	}
+	body, _, err := cache.Fetch(cache.CatalogKey(query.Encode()), cache.CatalogTTL, cacheBypassed(r), func() ([]byte, error) {
+		products, err := loadProducts(query)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(products)
+	})
+	if err != nil {
+		log.Println("Failed to load shop products:", err)
+		http.Error(w, "Failed to fetch from InventoryService", http.StatusInternalServerError)
+		return
+	}
+	var products []models.Product
+	if err := json.Unmarshal(body, &products); err != nil {
+		log.Println("Failed to decode shop products:", err)
+		http.Error(w, "Failed to fetch from InventoryService", http.StatusInternalServerError)
+		return
+	}
+
+	resp := Storefront{
+		Username:        shop.Username,
+		ShopName:        shop.ShopName,
+		ProfileImageURL: shop.ProfileImageURL,
+		Categories:      shopCategories(products),
+	}
+
+	// The category breakdown covers the whole shop; the filter only narrows
+	// the product page.
+	if category := r.URL.Query().Get("category"); category != "" {
+		names, err := categorySubtreeNames(ctx, category)
+		if err != nil {
+			log.Println("Failed to resolve category:", err)
+			http.Error(w, "Failed to fetch shop", http.StatusInternalServerError)
+			return
+		}
+		filtered := []models.Product{}
+		for _, p := range products {
+			if names[strings.ToLower(p.Category)] {
+				filtered = append(filtered, p)
+			}
+		}
+		products = filtered
+	}
+
+	resp.Page, resp.PageSize = parsePage(r)
+	resp.Total = len(products)
+	start := (resp.Page - 1) * resp.PageSize
+	if start > len(products) {
+		start = len(products)
+	}
+	end := start + resp.PageSize
+	if end > len(products) {
+		end = len(products)
+	}
+	resp.Products = products[start:end]
+
+	if resp.Ratings, err = shopRatings(ctx, shop.Username); err != nil {
+		log.Println("Failed to load shop ratings:", err)
+		http.Error(w, "Failed to fetch shop", http.StatusInternalServerError)
+		return
+	}
+	if resp.FollowerCount, err = followerCount(ctx, shop.Username); err != nil {
+		log.Println("Failed to count followers:", err)
+		http.Error(w, "Failed to fetch shop", http.StatusInternalServerError)
+		return
+	}
+
+	// The token is optional here; it only tells a buyer whether they follow.
+	if user, err := auth.FromHeader(r.Header.Get("Authorization")); err == nil && user.Role == "buyer" {
+		err := db.Pool.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM shop_followers WHERE seller_username = $1 AND buyer_id = $2)`,
+			shop.Username, user.ID).Scan(&resp.Following)
+		if err != nil {
+			log.Println("Failed to check follow status:", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// shopCategories counts the shop's products per category, largest first.
+func shopCategories(products []models.Product) []CategoryFacet {
+	counts := map[string]int{}
+	for _, p := range products {
+		counts[p.Category]++
+	}
+
+	facets := []CategoryFacet{}
+	for category, count := range counts {
+		facets = append(facets, CategoryFacet{Category: category, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Category < facets[j].Category
+	})
+	return facets
+}
+
+// shopRatings aggregates the published reviews across all of a seller's
+// products.
+func shopRatings(ctx context.Context, username string) (ShopRatings, error) {
+	ratings := ShopRatings{Breakdown: map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT rv.rating, COUNT(*)
+		FROM product_reviews rv
+		JOIN products p ON p.id = rv.product_id
+		WHERE p.seller_username = $1 AND rv.status = 'published'
+		GROUP BY rv.rating`, username)
+	if err != nil {
+		return ratings, err
+	}
+	defer rows.Close()
+
+	total := 0
+	for rows.Next() {
+		var rating int16
+		var count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return ratings, err
+		}
+		ratings.Breakdown[strconv.Itoa(int(rating))] = count
+		ratings.Count += count
+		total += int(rating) * count
+	}
+	if err := rows.Err(); err != nil {
+		return ratings, err
+	}
+
+	if ratings.Count > 0 {
+		// Rounded like the product ratings, which use SQL ROUND.
+		ratings.Average = math.Round(float64(total)/float64(ratings.Count)*100) / 100
+	}
+	return ratings, nil
+}
+
+func followerCount(ctx context.Context, username string) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM shop_followers WHERE seller_username = $1`, username).Scan(&count)
+	return count, err
+}
+
+// FollowShop handles POST /shops/{username}/follow for buyers.
+func FollowShop(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	ctx := r.Context()
+
+	shop, err := authclient.GetShop(ctx, chi.URLParam(r, "username"))
+	if errors.Is(err, authclient.ErrShopNotFound) {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("Failed to fetch shop profile:", err)
+		http.Error(w, "Failed to follow shop", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = db.Pool.Exec(ctx, `
+		INSERT INTO shop_followers (seller_username, buyer_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, shop.Username, user.ID)
+	if err != nil {
+		log.Println("Failed to follow shop:", err)
+		http.Error(w, "Failed to follow shop", http.StatusInternalServerError)
+		return
+	}
+
+	writeFollowStatus(w, r, shop.Username, true)
+}
+
+// UnfollowShop handles DELETE /shops/{username}/follow for buyers.
+func UnfollowShop(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(r)
+	username := chi.URLParam(r, "username")
+
+	_, err := db.Pool.Exec(r.Context(), `
+		DELETE FROM shop_followers WHERE seller_username = $1 AND buyer_id = $2`, username, user.ID)
+	if err != nil {
+		log.Println("Failed to unfollow shop:", err)
+		http.Error(w, "Failed to unfollow shop", http.StatusInternalServerError)
+		return
+	}
+
+	writeFollowStatus(w, r, username, false)
+}
+
+func writeFollowStatus(w http.ResponseWriter, r *http.Request, username string, following bool) {
+	count, err := followerCount(r.Context(), username)
+	if err != nil {
+		log.Println("Failed to count followers:", err)
+		http.Error(w, "Failed to count followers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"username":       username,
+		"following":      following,
+		"follower_count": count,
+	})
+}
@@ -0,0 +1,140 @@
+package live
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"ProductService/redis"
+)
+
+// clientBuffer is how many undelivered messages a client may have queued
+// before it is treated as a slow consumer and disconnected.
+const clientBuffer = 32
+
+type Message struct {
+	Event string
+	Data  []byte
+}
+
+type Client struct {
+	products map[int]struct{}
+	send     chan Message
+	// Dropped is closed when the hub disconnects the client for falling behind.
+	Dropped chan struct{}
+}
+
+func (c *Client) Messages() <-chan Message {
+	return c.send
+}
+
+type StockUpdate struct {
+	ProductID     int  `json:"product_id"`
+	VariantID     int  `json:"variant_id"`
+	StockQuantity int  `json:"stock_quantity"`
+	PreviousStock *int `json:"previous_stock,omitempty"`
+	Version       int  `json:"version"`
+}
+
+// PriceUpdate carries a product's new base price, or a variant's new price
+// override; a variant update without one sells at the base price.
+type PriceUpdate struct {
+	ProductID     int      `json:"product_id"`
+	VariantID     int      `json:"variant_id,omitempty"`
+	BasePrice     *float64 `json:"base_price,omitempty"`
+	PriceOverride *float64 `json:"price_override,omitempty"`
+	Version       int      `json:"version"`
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = make(map[int]map[*Client]struct{})
+)
+
+// Subscribe registers a client for updates to the given product IDs.
+func Subscribe(productIDs []int) *Client {
+	c := &Client{
+		products: make(map[int]struct{}, len(productIDs)),
+		send:     make(chan Message, clientBuffer),
+		Dropped:  make(chan struct{}),
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range productIDs {
+		c.products[id] = struct{}{}
+		if subscribers[id] == nil {
+			subscribers[id] = make(map[*Client]struct{})
+		}
+		subscribers[id][c] = struct{}{}
+	}
+	return c
+}
+
+// Unsubscribe removes a client; it is safe to call more than once.
+func Unsubscribe(c *Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	unsubscribeLocked(c)
+}
+
+func unsubscribeLocked(c *Client) {
+	for id := range c.products {
+		delete(subscribers[id], c)
+		if len(subscribers[id]) == 0 {
+			delete(subscribers, id)
+		}
+	}
+	c.products = nil
+}
+
+// publish fans msg out to every client watching productID without blocking;
+// a client whose buffer is full is dropped.
+func publish(productID int, msg Message) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for c := range subscribers[productID] {
+		select {
+		case c.send <- msg:
+		default:
+			log.Printf("⚠️ Dropping slow live client subscribed to product %d", productID)
+			unsubscribeLocked(c)
+			close(c.Dropped)
+		}
+	}
+}
+
+// HandleInventoryEvent forwards stock and price changes to live clients.
+// Product and variant events fire for any column change; InventoryService
+// flags the ones that moved a price.
+func HandleInventoryEvent(event redis.InventoryEvent) {
+	if event.VariantID != 0 && event.StockQuantity != nil {
+		data, _ := json.Marshal(StockUpdate{
+			ProductID:     event.ProductID,
+			VariantID:     event.VariantID,
+			StockQuantity: *event.StockQuantity,
+			PreviousStock: event.PreviousStock,
+			Version:       event.Version,
+		})
+		publish(event.ProductID, Message{Event: "stock", Data: data})
+	}
+
+	if !event.PriceChanged {
+		return
+	}
+	update := PriceUpdate{
+		ProductID: event.ProductID,
+		VariantID: event.VariantID,
+		Version:   event.Version,
+	}
+	if event.VariantID != 0 {
+		update.PriceOverride = event.PriceOverride
+	} else if event.BasePrice != nil {
+		update.BasePrice = event.BasePrice
+	} else {
+		return
+	}
+	data, _ := json.Marshal(update)
+	publish(event.ProductID, Message{Event: "price", Data: data})
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+    "context"
     "log"
     "net/http"
 
     "github.com/go-chi/chi/v5"
     "github.com/go-chi/cors"
+    "auth"
+    "ProductService/cache"
+    "ProductService/db"
     "ProductService/handlers"
+    "ProductService/live"
     "ProductService/redis"
+    "ProductService/suggest"
 )
 
 func main() {
@@ -17,19 +23,48 @@ func main() {
     r.Use(cors.Handler(cors.Options{
         AllowedOrigins:   []string{"http://localhost:3000"}, // Or use []string{"*"} for all origins (dev only)
         AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-        AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-        ExposedHeaders:   []string{"Link"},
+        AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", cache.BypassHeader},
+        ExposedHeaders:   []string{"Link", "X-Cache"},
         AllowCredentials: true,
         MaxAge:           300, // Maximum value not ignored by any of major browsers
     }))
 
     r.Get("/products", handlers.GetProducts)
 	r.Get("/categories", handlers.GetCategories)
+    r.Get("/categories/tree", handlers.GetCategoryTree)
     r.Get("/products/{id}", handlers.GetProductByID)
+    r.Get("/products/{id}/availability", handlers.GetProductAvailability)
+    r.Get("/products/{id}/reviews", handlers.GetProductReviews)
+    r.With(auth.RequireRole("buyer")).Post("/products/{id}/reviews", handlers.CreateReview)
+    r.With(auth.RequireRole("seller")).Post("/reviews/{id}/reply", handlers.ReplyToReview)
+    r.Get("/shops/{username}", handlers.GetShop)
+    r.With(auth.RequireRole("buyer")).Post("/shops/{username}/follow", handlers.FollowShop)
+    r.With(auth.RequireRole("buyer")).Delete("/shops/{username}/follow", handlers.UnfollowShop)
+    r.Get("/search", handlers.SearchProducts)
+    r.Get("/search/suggest", handlers.SuggestProducts)
+    r.Get("/live/products", handlers.LiveProductUpdates)
+    r.Get("/metrics/cache", handlers.GetCacheStats)
 
+    // Category management (admin only)
+    r.Route("/admin/categories", func(r chi.Router) {
+        r.Use(auth.RequireRole("admin"))
+        r.Post("/", handlers.CreateCategory)
+        r.Put("/{id}", handlers.UpdateCategory)
+        r.Delete("/{id}", handlers.DeleteCategory)
+    })
+    r.With(auth.RequireRole("admin")).Put("/admin/reviews/{id}", handlers.ModerateReview)
 
+    db.Init()
+    redis.Init()
+    redis.OnInventoryEvent(cache.HandleInventoryEvent)
+    redis.OnInventoryEvent(suggest.HandleInventoryEvent)
+    redis.OnInventoryEvent(live.HandleInventoryEvent)
     go redis.SubscribeToInventoryEvents()
 
+    if err := suggest.Load(context.Background()); err != nil {
+        log.Printf("❌ Failed to load suggest index: %v", err)
+    }
+
     log.Println("ProductService running on :8001")
     log.Fatal(http.ListenAndServe(":8001", r))
 }
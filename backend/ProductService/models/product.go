@@ -0,0 +1,39 @@
+package models
+
+type Product struct {
+	ID              int              `json:"id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	BasePrice       float64          `json:"base_price"`
+	Image           string           `json:"image"`
+	Category        string           `json:"category"`
+	SKU             string           `json:"sku"`
+	Listed          bool             `json:"listed"`
+	SellerID        int              `json:"seller_id"`
+	SellerUsername  string           `json:"seller_username"`
+	CreatedAt       string           `json:"created_at"`
+	UpdatedAt       string           `json:"updated_at"`
+	RatingAverage   float64          `json:"rating_average"`
+	RatingCount     int              `json:"rating_count"`
+	RatingBreakdown map[string]int   `json:"rating_breakdown,omitempty"` // Only used for Product Details
+	ProductVariants []ProductVariant `json:"product_variants,omitempty"` // Only used for Product Details
+}
+
+type ProductVariant struct {
+	ID               int      `json:"id"`
+	ProductID        int      `json:"product_id"`
+	VariantName      string   `json:"variant_name"`
+	Size             string   `json:"size"`
+	Color            string   `json:"color"`
+	SKU              string   `json:"sku"`
+	StockQuantity    int      `json:"stock_quantity"`
+	Available        int      `json:"available_to_sell"` // stock_quantity minus active checkout reservations, plus any backorder allowance
+	Image            string   `json:"image"`
+	Price            float64  `json:"price"`              // price_override, or the product's base_price
+	PriceOverride    *float64 `json:"price_override"`     // nil when the variant sells at base_price
+	CompareAtPrice   *float64 `json:"compare_at_price"`   // nil when there is no "was" price
+	FulfilmentMode   string   `json:"fulfilment_mode"`    // in_stock, preorder or backorder
+	ExpectedShipDate *string  `json:"expected_ship_date"` // YYYY-MM-DD, set for preorders
+	CreatedAt        string   `json:"created_at"`
+	UpdatedAt        string   `json:"updated_at"`
+}
@@ -0,0 +1,119 @@
+package orderclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+func endpoint() string {
+	if url := os.Getenv("ORDER_HASURA_ENDPOINT"); url != "" {
+		return url
+	}
+	return "http://hasura-order:8080/v1/graphql"
+}
+
+// run executes a GraphQL query against the orderdb Hasura with admin rights
+// and decodes the data field into out.
+func run(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal GraphQL payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint(), bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-hasura-admin-secret", "password")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("hasura request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode hasura response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("hasura error: %s", result.Errors[0].Message)
+	}
+
+	return json.Unmarshal(result.Data, out)
+}
+
+// UnitsSoldByProduct sums ordered quantities per product ID.
+func UnitsSoldByProduct(ctx context.Context) (map[int]int, error) {
+	var resp struct {
+		OrderItems []struct {
+			ProductID int `json:"product_id"`
+			Quantity  int `json:"quantity"`
+		} `json:"order_items"`
+	}
+
+	err := run(ctx, `
+	query UnitsSold {
+		order_items(where: { order: { status: { _nin: ["cancelled", "failed"] } } }) {
+			product_id
+			quantity
+		}
+	}`, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	sold := make(map[int]int)
+	for _, item := range resp.OrderItems {
+		sold[item.ProductID] += item.Quantity
+	}
+	return sold, nil
+}
+
+// DeliveredOrderID returns the ID of a delivered order in which buyerID
+// bought productID, or 0 if there is none.
+func DeliveredOrderID(ctx context.Context, buyerID, productID int) (int, error) {
+	var resp struct {
+		OrderItems []struct {
+			OrderID int `json:"order_id"`
+		} `json:"order_items"`
+	}
+
+	err := run(ctx, `
+	query DeliveredItem($buyerId: Int!, $productId: Int!) {
+		order_items(
+			where: {
+				product_id: { _eq: $productId },
+				order: { buyer_id: { _eq: $buyerId }, status: { _in: ["delivered", "completed"] } }
+			},
+			order_by: { order_id: desc },
+			limit: 1
+		) {
+			order_id
+		}
+	}`, map[string]interface{}{
+		"buyerId":   buyerID,
+		"productId": productID,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp.OrderItems) == 0 {
+		return 0, nil
+	}
+	return resp.OrderItems[0].OrderID, nil
+}
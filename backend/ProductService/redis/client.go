@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"log"
+	"os"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Client is the shared Redis connection used for pub/sub and caching.
+var Client *redis.Client
+
+func Init() {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379" // change if using Docker
+	}
+
+	Client = redis.NewClient(&redis.Options{
+		Addr: addr,
+	})
+
+	if err := Client.Ping(ctx).Err(); err != nil {
+		log.Printf("⚠️ Redis not reachable at %s: %v", addr, err)
+		return
+	}
+	log.Printf("✅ Connected to Redis at %s", addr)
+}
@@ -2,23 +2,61 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"log"
-
-	"github.com/go-redis/redis/v8"
+	"sync"
 )
 
 var ctx = context.Background()
 
-func SubscribeToInventoryEvents() {
-	rdb := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379", // change if using Docker
-	})
+// InventoryEvent is the payload published on the product_created and
+// product_updated channels.
+type InventoryEvent struct {
+	Channel       string   `json:"-"`
+	Type          string   `json:"type"`
+	ProductID     int      `json:"product_id"`
+	VariantID     int      `json:"variant_id,omitempty"`
+	StockQuantity *int     `json:"stock_quantity,omitempty"`
+	PreviousStock *int     `json:"previous_stock,omitempty"`
+	Available     *int     `json:"available_to_sell,omitempty"`
+	BasePrice     *float64 `json:"base_price,omitempty"`
+	PriceOverride *float64 `json:"price_override,omitempty"`
+	PriceChanged  bool     `json:"price_changed,omitempty"`
+	Listed        *bool    `json:"listed,omitempty"`
+	Version       int      `json:"version"`
+}
+
+var (
+	listenersMu sync.RWMutex
+	listeners   []func(InventoryEvent)
+)
 
-	pubsub := rdb.Subscribe(ctx, "product_created", "product_updated")
+// OnInventoryEvent registers fn to be called for every event received by
+// SubscribeToInventoryEvents.
+func OnInventoryEvent(fn func(InventoryEvent)) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+func SubscribeToInventoryEvents() {
+	pubsub := Client.Subscribe(ctx, "product_created", "product_updated")
 	ch := pubsub.Channel()
 
 	for msg := range ch {
 		log.Printf("Received event: %s", msg.Payload)
-		// You could broadcast this via WebSocket or store in cache
+
+		var event InventoryEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Printf("❌ Failed to decode inventory event: %v", err)
+			continue
+		}
+		event.Channel = msg.Channel
+
+		listenersMu.RLock()
+		for _, fn := range listeners {
+			fn(event)
+		}
+		listenersMu.RUnlock()
 	}
 }
@@ -0,0 +1,330 @@
+package suggest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"ProductService/authclient"
+	"ProductService/db"
+	"ProductService/orderclient"
+	"ProductService/redis"
+)
+
+const (
+	KindProduct  = "product"
+	KindCategory = "category"
+	KindShop     = "shop"
+)
+
+type Suggestion struct {
+	Kind       string `json:"kind"`
+	Text       string `json:"text"`
+	ProductID  int    `json:"product_id,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Popularity int    `json:"popularity"`
+}
+
+type Suggestions struct {
+	Products   []Suggestion `json:"products"`
+	Categories []Suggestion `json:"categories"`
+	Shops      []Suggestion `json:"shops"`
+}
+
+type product struct {
+	ID             int
+	Name           string
+	Category       string
+	SellerUsername string
+}
+
+// aggregate tracks how many listed products back a category or shop entry
+// and how many units those products sold.
+type aggregate struct {
+	products int
+	sold     int
+}
+
+type index struct {
+	trie       *trie
+	entries    map[string]*Suggestion
+	terms      map[string][]string
+	products   map[int]product
+	categories map[string]*aggregate
+	shops      map[string]*aggregate
+	shopNames  map[string]string
+	unitsSold  map[int]int
+}
+
+func newIndex(shopNames map[string]string, unitsSold map[int]int) *index {
+	return &index{
+		trie:       newTrie(),
+		entries:    make(map[string]*Suggestion),
+		terms:      make(map[string][]string),
+		products:   make(map[int]product),
+		categories: make(map[string]*aggregate),
+		shops:      make(map[string]*aggregate),
+		shopNames:  shopNames,
+		unitsSold:  unitsSold,
+	}
+}
+
+var (
+	mu      sync.RWMutex
+	current = newIndex(map[string]string{}, map[int]int{})
+)
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// termsFor returns the text starting at every word, so "blue cotton shirt"
+// is found by "blue", "cotton" and "shirt" prefixes alike.
+func termsFor(text string) []string {
+	words := strings.Fields(normalize(text))
+	terms := make([]string, 0, len(words))
+	for i := range words {
+		terms = append(terms, strings.Join(words[i:], " "))
+	}
+	return terms
+}
+
+func (idx *index) put(key string, s Suggestion) {
+	if existing, ok := idx.entries[key]; ok {
+		if existing.Text == s.Text {
+			*existing = s
+			return
+		}
+		idx.drop(key)
+	}
+
+	entry := s
+	idx.entries[key] = &entry
+	idx.terms[key] = termsFor(s.Text)
+	for _, term := range idx.terms[key] {
+		idx.trie.insert(term, key)
+	}
+}
+
+func (idx *index) drop(key string) {
+	for _, term := range idx.terms[key] {
+		idx.trie.remove(term, key)
+	}
+	delete(idx.entries, key)
+	delete(idx.terms, key)
+}
+
+func (idx *index) adjust(kind, name string, products, sold int) {
+	if name == "" {
+		return
+	}
+
+	aggregates := idx.categories
+	key := "category:" + normalize(name)
+	if kind == KindShop {
+		aggregates = idx.shops
+		key = "shop:" + name
+	}
+
+	agg, ok := aggregates[key]
+	if !ok {
+		agg = &aggregate{}
+		aggregates[key] = agg
+	}
+	agg.products += products
+	agg.sold += sold
+
+	if agg.products <= 0 {
+		delete(aggregates, key)
+		idx.drop(key)
+		return
+	}
+
+	s := Suggestion{Kind: kind, Text: name, Popularity: agg.sold + agg.products}
+	if kind == KindShop {
+		s.Username = name
+		if shopName, ok := idx.shopNames[name]; ok {
+			s.Text = shopName
+		}
+	}
+	idx.put(key, s)
+}
+
+func (idx *index) upsertProduct(p product) {
+	idx.removeProduct(p.ID)
+
+	sold := idx.unitsSold[p.ID]
+	idx.products[p.ID] = p
+	idx.put(fmt.Sprintf("product:%d", p.ID), Suggestion{
+		Kind:       KindProduct,
+		Text:       p.Name,
+		ProductID:  p.ID,
+		Popularity: sold,
+	})
+	idx.adjust(KindCategory, p.Category, 1, sold)
+	idx.adjust(KindShop, p.SellerUsername, 1, sold)
+}
+
+func (idx *index) removeProduct(id int) {
+	old, ok := idx.products[id]
+	if !ok {
+		return
+	}
+
+	sold := idx.unitsSold[id]
+	delete(idx.products, id)
+	idx.drop(fmt.Sprintf("product:%d", id))
+	idx.adjust(KindCategory, old.Category, -1, -sold)
+	idx.adjust(KindShop, old.SellerUsername, -1, -sold)
+}
+
+func (idx *index) lookup(prefix string, limit int) Suggestions {
+	result := Suggestions{
+		Products:   []Suggestion{},
+		Categories: []Suggestion{},
+		Shops:      []Suggestion{},
+	}
+
+	for key := range idx.trie.find(prefix) {
+		s := *idx.entries[key]
+		switch s.Kind {
+		case KindProduct:
+			result.Products = append(result.Products, s)
+		case KindCategory:
+			result.Categories = append(result.Categories, s)
+		case KindShop:
+			result.Shops = append(result.Shops, s)
+		}
+	}
+
+	result.Products = top(result.Products, limit)
+	result.Categories = top(result.Categories, limit)
+	result.Shops = top(result.Shops, limit)
+	return result
+}
+
+func top(list []Suggestion, limit int) []Suggestion {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Popularity != list[j].Popularity {
+			return list[i].Popularity > list[j].Popularity
+		}
+		return list[i].Text < list[j].Text
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// Lookup returns up to limit suggestions of each kind whose text has a word
+// starting with prefix, most popular first.
+func Lookup(prefix string, limit int) Suggestions {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.lookup(normalize(prefix), limit)
+}
+
+// Load rebuilds the index from inventorydb, with popularity taken from units
+// sold in orderdb and shop names from AuthService.
+func Load(ctx context.Context) error {
+	unitsSold, err := orderclient.UnitsSoldByProduct(ctx)
+	if err != nil {
+		log.Printf("⚠️ Suggest index loaded without popularity: %v", err)
+		unitsSold = map[int]int{}
+	}
+
+	idx := newIndex(loadShopNames(ctx), unitsSold)
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, name, category, seller_username
+		FROM products
+		WHERE listed`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category, &p.SellerUsername); err != nil {
+			return err
+		}
+		idx.upsertProduct(p)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	current = idx
+	mu.Unlock()
+
+	log.Printf("✅ Suggest index loaded with %d products", len(idx.products))
+	return nil
+}
+
+func loadShopNames(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+	shops, err := authclient.ListShops(ctx)
+	if err != nil {
+		log.Printf("⚠️ Suggest index loaded without shop names: %v", err)
+		return names
+	}
+	for _, shop := range shops {
+		names[shop.Username] = shop.ShopName
+	}
+	return names
+}
+
+// refreshProduct re-reads one product and updates or removes its entries.
+func refreshProduct(id int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var p product
+	var listed bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, name, category, seller_username, listed
+		FROM products
+		WHERE id = $1`, id).Scan(&p.ID, &p.Name, &p.Category, &p.SellerUsername, &listed)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("❌ Suggest index refresh failed for product %d: %v", id, err)
+		return
+	}
+
+	mu.RLock()
+	_, knownShop := current.shopNames[p.SellerUsername]
+	mu.RUnlock()
+
+	var shopNames map[string]string
+	if err == nil && listed && !knownShop {
+		shopNames = loadShopNames(ctx)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if shopNames != nil {
+		current.shopNames = shopNames
+	}
+	if err != nil || !listed {
+		current.removeProduct(id)
+		return
+	}
+	current.upsertProduct(p)
+}
+
+// HandleInventoryEvent keeps the index current with product inserts and
+// updates. Variant and stock events do not change any suggestion text.
+func HandleInventoryEvent(event redis.InventoryEvent) {
+	if event.Type != "product_created" && event.Type != "product_updated" {
+		return
+	}
+	go refreshProduct(event.ProductID)
+}
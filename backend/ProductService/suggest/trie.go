@@ -0,0 +1,64 @@
+package suggest
+
+// node keeps the keys of every entry reachable below it, so a prefix lookup
+// is a walk down the prefix followed by a read of one set.
+type node struct {
+	children map[rune]*node
+	entries  map[string]struct{}
+}
+
+func newNode() *node {
+	return &node{
+		children: make(map[rune]*node),
+		entries:  make(map[string]struct{}),
+	}
+}
+
+type trie struct {
+	root *node
+}
+
+func newTrie() *trie {
+	return &trie{root: newNode()}
+}
+
+func (t *trie) insert(term, key string) {
+	n := t.root
+	for _, r := range term {
+		child, ok := n.children[r]
+		if !ok {
+			child = newNode()
+			n.children[r] = child
+		}
+		child.entries[key] = struct{}{}
+		n = child
+	}
+}
+
+func (t *trie) remove(term, key string) {
+	n := t.root
+	for _, r := range term {
+		child, ok := n.children[r]
+		if !ok {
+			return
+		}
+		delete(child.entries, key)
+		if len(child.entries) == 0 {
+			delete(n.children, r)
+			return
+		}
+		n = child
+	}
+}
+
+func (t *trie) find(prefix string) map[string]struct{} {
+	n := t.root
+	for _, r := range prefix {
+		child, ok := n.children[r]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n.entries
+}
@@ -0,0 +1,108 @@
+// Package auth identifies the callers of the backend services by the JWT
+// AuthService issues.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// User is the caller identified by the AuthService JWT.
+type User struct {
+	ID       int
+	Username string
+	Role     string
+	ShopName string
+}
+
+type contextKey struct{}
+
+func secret() []byte {
+	if s := os.Getenv("JWT_SECRET"); s != "" {
+		return []byte(s)
+	}
+	return []byte("doma-ecommerce-system-jwt-secret-key")
+}
+
+// FromHeader parses a "Bearer <token>" Authorization header.
+func FromHeader(authHeader string) (*User, error) {
+	if authHeader == "" {
+		return nil, fmt.Errorf("missing token")
+	}
+
+	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return secret(), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid claims")
+	}
+
+	hasuraClaims, ok := claims["https://hasura.io/jwt/claims"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("missing hasura claims")
+	}
+
+	userIDStr, ok := hasuraClaims["x-hasura-user-id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing user ID")
+	}
+	id, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format")
+	}
+
+	user := &User{ID: id}
+	user.Username, _ = hasuraClaims["x-hasura-user-name"].(string)
+	user.Role, _ = claims["role"].(string)
+	user.ShopName, _ = claims["shop_name"].(string)
+	return user, nil
+}
+
+// RequireRole rejects requests without a valid token for one of roles and
+// stores the caller in the request context.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := FromHeader(r.Header.Get("Authorization"))
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			allowed := len(roles) == 0
+			for _, role := range roles {
+				if user.Role == role {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, user)))
+		})
+	}
+}
+
+// CurrentUser returns the caller stored by RequireRole.
+func CurrentUser(r *http.Request) *User {
+	user, _ := r.Context().Value(contextKey{}).(*User)
+	return user
+}
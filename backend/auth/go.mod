@@ -0,0 +1,5 @@
+module auth
+
+go 1.24.0
+
+require github.com/golang-jwt/jwt v3.2.2+incompatible
@@ -0,0 +1,121 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultDeadLetterLimit = 20
+	maxDeadLetterLimit     = 100
+)
+
+// DeadLetterQueue is the response of GET <prefix>/{queue}.
+type DeadLetterQueue struct {
+	Queue    string       `json:"queue"`
+	Depth    int          `json:"depth"`
+	Messages []DeadLetter `json:"messages"`
+}
+
+// DeadLetterHandler serves the dead-letter admin API under prefix:
+//
+//	GET    <prefix>/{queue}?limit=         the oldest messages, left in place
+//	POST   <prefix>/{queue}/replay?limit=  put messages back on the queue,
+//	                                       all of them without a limit
+//	DELETE <prefix>/{queue}                drop every message
+//
+// Like the operations it wraps it works on any queue on the broker. The
+// service mounting it is responsible for allowing only admins through.
+func (c *Client) DeadLetterHandler(prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		queue, action, _ := strings.Cut(path, "/")
+		switch {
+		case queue == "":
+			http.NotFound(w, r)
+		case action == "" && r.Method == http.MethodGet:
+			c.getDeadLetters(w, r, queue)
+		case action == "" && r.Method == http.MethodDelete:
+			c.purgeDeadLetters(w, queue)
+		case action == "replay" && r.Method == http.MethodPost:
+			c.replayDeadLetters(w, r, queue)
+		case action == "" || action == "replay":
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func (c *Client) getDeadLetters(w http.ResponseWriter, r *http.Request, queue string) {
+	limit, ok := limitParam(r, defaultDeadLetterLimit)
+	if !ok || limit > maxDeadLetterLimit {
+		http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	messages, depth, err := c.DeadLetters(queue, limit)
+	if err != nil {
+		writeDeadLetterError(w, err, "read", queue)
+		return
+	}
+
+	writeJSON(w, DeadLetterQueue{Queue: queue, Depth: depth, Messages: messages})
+}
+
+func (c *Client) replayDeadLetters(w http.ResponseWriter, r *http.Request, queue string) {
+	limit, ok := limitParam(r, math.MaxInt32)
+	if !ok {
+		http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	replayed, err := c.ReplayDeadLetters(queue, limit)
+	if err != nil {
+		writeDeadLetterError(w, err, "replay", queue)
+		return
+	}
+
+	log.Printf("♻️ Replayed %d dead-lettered message(s) onto %s", replayed, queue)
+	writeJSON(w, map[string]int{"replayed": replayed})
+}
+
+func (c *Client) purgeDeadLetters(w http.ResponseWriter, queue string) {
+	purged, err := c.PurgeDeadLetters(queue)
+	if err != nil {
+		writeDeadLetterError(w, err, "purge", queue)
+		return
+	}
+
+	log.Printf("🗑️ Purged %d dead-lettered message(s) of %s", purged, queue)
+	writeJSON(w, map[string]int{"purged": purged})
+}
+
+// limitParam reads the limit query param, defaulting to fallback.
+func limitParam(r *http.Request, fallback int) (int, bool) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
+
+func writeDeadLetterError(w http.ResponseWriter, err error, action, queue string) {
+	if errors.Is(err, ErrNoDeadLetterQueue) {
+		http.Error(w, "Queue has no dead-letter queue", http.StatusNotFound)
+		return
+	}
+	log.Printf("❌ Failed to %s dead letters of %s: %v", action, queue, err)
+	http.Error(w, "Failed to "+action+" dead letters", http.StatusBadGateway)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
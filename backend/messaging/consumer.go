@@ -0,0 +1,218 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Handler processes one delivery. Returning nil acks it; any other error
+// sends it on to retry, and a Permanent error straight to the dead-letter
+// queue.
+type Handler func(amqp.Delivery) error
+
+// A failed message is republished to the next retry queue of its queue.
+// Retry queues hold messages for their delay and then dead-letter them
+// back to the original queue through the default exchange. After the last
+// retry the message is parked in the queue's .dlq, see DeadLetters.
+var retryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+// requeueDelay holds back a message that could not be moved to its retry
+// queue, so it is not redelivered in a tight loop while that keeps failing.
+const requeueDelay = 5 * time.Second
+
+const (
+	retryCountHeader    = "x-retry-count"
+	lastErrorHeader     = "x-last-error"
+	failedAtHeader      = "x-failed-at"
+	originalQueueHeader = "x-original-queue"
+)
+
+// consumer is a queue bound to Exchange; without a handler the queue is
+// only declared.
+type consumer struct {
+	queue    string
+	keys     []string
+	prefetch int
+	handle   Handler
+}
+
+// Subscribe binds a durable queue to the event types in keys and consumes
+// it with handle, now if the client is connected and again after every
+// reconnect. prefetch caps the messages in flight for this queue.
+func (c *Client) Subscribe(queue string, keys []string, prefetch int, handle Handler) {
+	c.add(&consumer{queue: queue, keys: keys, prefetch: prefetch, handle: handle})
+}
+
+// Declare binds a durable queue to the event types in keys without
+// consuming it, so events nobody handles yet are kept until somebody does.
+func (c *Client) Declare(queue string, keys ...string) {
+	c.add(&consumer{queue: queue, keys: keys})
+}
+
+func (c *Client) add(cons *consumer) {
+	c.mu.Lock()
+	c.consumers = append(c.consumers, cons)
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn != nil {
+		if err := c.subscribe(conn, cons); err != nil {
+			log.Printf("❌ Failed to subscribe to %s, reconnecting: %v", cons.queue, err)
+			conn.Close()
+		}
+	}
+}
+
+func (c *Client) subscribe(conn *amqp.Connection, cons *consumer) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(cons.queue, true, false, false, false, nil); err != nil {
+		return err
+	}
+	for _, key := range cons.keys {
+		if err := ch.QueueBind(cons.queue, key, Exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	if cons.handle == nil {
+		return ch.Close()
+	}
+
+	if err := ch.Qos(cons.prefetch, 0, false); err != nil {
+		return err
+	}
+	if err := declareRetryQueues(ch, cons.queue); err != nil {
+		return err
+	}
+	msgs, err := ch.Consume(cons.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("📦 Listening to %s...", cons.queue)
+
+	go func() {
+		for d := range msgs {
+			c.settle(cons.queue, d, cons.handle(d))
+		}
+		// The channel closed under a live connection; reconnecting is
+		// what re-subscribes it.
+		if !conn.IsClosed() {
+			log.Printf("⚠️ Consumer channel for %s closed, reconnecting", cons.queue)
+			conn.Close()
+		}
+	}()
+	return nil
+}
+
+func retryQueue(queue string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queue, attempt)
+}
+
+func deadLetterQueue(queue string) string {
+	return queue + ".dlq"
+}
+
+// declareRetryQueues declares the retry queues and dead-letter queue of queue.
+func declareRetryQueues(ch *amqp.Channel, queue string) error {
+	for i, delay := range retryDelays {
+		_, err := ch.QueueDeclare(retryQueue(queue, i+1), true, false, false, false, amqp.Table{
+			"x-message-ttl":             int32(delay / time.Millisecond),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err := ch.QueueDeclare(deadLetterQueue(queue), true, false, false, false, nil)
+	return err
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a failure retrying cannot fix, such as a malformed
+// message.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+func retryCount(d amqp.Delivery) int {
+	switch n := d.Headers[retryCountHeader].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+// retryTarget picks the queue a delivery that failed with err moves to and
+// the attempt it was: the next retry queue, or the dead-letter queue once
+// the retries are used up or the failure is permanent.
+func retryTarget(queue string, d amqp.Delivery, err error) (string, int) {
+	attempt := retryCount(d) + 1
+	var perm permanentError
+	if !errors.As(err, &perm) && attempt <= len(retryDelays) {
+		return retryQueue(queue, attempt), attempt
+	}
+	return deadLetterQueue(queue), attempt
+}
+
+// settle acks d once it is handled, or once it is confirmed on its next
+// retry queue or the dead-letter queue. If that publish fails the message
+// is requeued as is after requeueDelay.
+func (c *Client) settle(queue string, d amqp.Delivery, err error) {
+	if err == nil {
+		d.Ack(false)
+		return
+	}
+
+	target, attempt := retryTarget(queue, d, err)
+
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[retryCountHeader] = int32(attempt)
+	headers[lastErrorHeader] = err.Error()
+	headers[failedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+	headers[originalQueueHeader] = queue
+
+	pubErr := c.Publish(context.Background(), "", target, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     d.MessageId,
+		CorrelationId: d.CorrelationId,
+		Type:          d.Type,
+		AppId:         d.AppId,
+		Timestamp:     d.Timestamp,
+		Body:          d.Body,
+	})
+	if pubErr != nil {
+		log.Printf("❌ Failed to move message from %s to %s, requeueing in %s: %v", queue, target, requeueDelay, pubErr)
+		time.Sleep(requeueDelay)
+		d.Nack(false, true)
+		return
+	}
+
+	if target == deadLetterQueue(queue) {
+		log.Printf("☠️ Dead-lettered message from %s after %d attempt(s): %v", queue, attempt, err)
+	} else {
+		log.Printf("🔁 Retrying message from %s in %s (attempt %d): %v", queue, retryDelays[attempt-1], attempt, err)
+	}
+	d.Ack(false)
+}
@@ -0,0 +1,37 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestRetryTarget(t *testing.T) {
+	failure := errors.New("database unavailable")
+	tests := []struct {
+		name        string
+		retries     interface{}
+		err         error
+		wantQueue   string
+		wantAttempt int
+	}{
+		{"first failure", nil, failure, "orders.retry.1", 1},
+		{"second failure", int32(1), failure, "orders.retry.2", 2},
+		{"int64 header", int64(3), failure, "orders.retry.4", 4},
+		{"retries used up", int32(len(retryDelays)), failure, "orders.dlq", len(retryDelays) + 1},
+		{"permanent", nil, Permanent(failure), "orders.dlq", 1},
+		{"wrapped permanent", int32(1), fmt.Errorf("order 7: %w", Permanent(failure)), "orders.dlq", 2},
+	}
+	for _, tt := range tests {
+		d := amqp.Delivery{Headers: amqp.Table{}}
+		if tt.retries != nil {
+			d.Headers[retryCountHeader] = tt.retries
+		}
+		queue, attempt := retryTarget("orders", d, tt.err)
+		if queue != tt.wantQueue || attempt != tt.wantAttempt {
+			t.Errorf("%s: got %s attempt %d, want %s attempt %d", tt.name, queue, attempt, tt.wantQueue, tt.wantAttempt)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package contracts
+
+// Event types double as routing keys on the domain.events exchange:
+// <aggregate>.<what happened>.
+const (
+	OrderPlaced    = "order.placed"    // OrderService: stock to hold for a new order
+	OrderConfirmed = "order.confirmed" // OrderService: paid, its held stock can be taken
+	OrderCancelled = "order.cancelled" // OrderService: stock to put back
+
+	PaymentRequested = "payment.requested" // paymentservice: a new order to record a payment for
+	PaymentRefunded  = "payment.refunded"  // paymentservice: stock to put back
+
+	ReturnReceived = "return.received" // returns: stock to put back
+
+	StockRejected           = "stock.rejected"            // InventoryService: order could not be fulfilled
+	StockBackordered        = "stock.backordered"         // InventoryService: lines waiting for stock
+	StockBackorderFulfilled = "stock.backorder_fulfilled" // InventoryService: a waiting line can ship
+	StockAlert              = "stock.alert"               // InventoryService: variant went low or sold out
+	StockLowStockDigest     = "stock.low_stock_digest"    // InventoryService: seller's daily low-stock list
+	StockBackInStock        = "stock.back_in_stock"       // InventoryService: buyers to notify of a restock
+)
+
+var orderItem = object(Schema{
+	"variant_id": req(Integer),
+	"quantity":   req(Integer),
+})
+
+// schemas holds every version of every event; a new version is added
+// next to the old ones while consumers still read them.
+var schemas = map[string]map[int]Schema{
+	OrderPlaced: {1: {
+		"order_id":          req(Integer),
+		"buyer_id":          opt(Integer),
+		"reservation_id":    opt(Integer),
+		"shipping_province": opt(String),
+		"awaiting_payment":  opt(Boolean),
+		"items":             reqArray(orderItem),
+	}},
+	OrderConfirmed: {1: {
+		"order_id": req(Integer),
+	}},
+	OrderCancelled: {1: {
+		"order_id": req(Integer),
+		"reason":   opt(String),
+	}},
+	PaymentRequested: {1: {
+		"order_id":         req(Integer),
+		"user_id":          req(Integer),
+		"amount":           req(Number),
+		"currency":         req(String),
+		"payment_method":   req(String),
+		"payment_provider": opt(String),
+	}},
+	PaymentRefunded: {1: {
+		"order_id":   req(Integer),
+		"payment_id": opt(Integer),
+	}},
+	ReturnReceived: {1: {
+		"order_id":  req(Integer),
+		"return_id": opt(String),
+		"reason":    opt(String),
+		"items":     {Kind: Array, Elem: &orderItem},
+	}},
+	StockRejected: {1: {
+		"order_id": req(Integer),
+		"reason":   req(String),
+		"items": reqArray(object(Schema{
+			"variant_id": req(Integer),
+			"requested":  req(Integer),
+			"available":  req(Integer),
+		})),
+	}},
+	StockBackordered: {1: {
+		"order_id": req(Integer),
+		"items": reqArray(object(Schema{
+			"variant_id":         req(Integer),
+			"quantity":           req(Integer),
+			"fulfilment_mode":    req(String),
+			"expected_ship_date": opt(Date),
+		})),
+	}},
+	StockBackorderFulfilled: {1: {
+		"order_id":   req(Integer),
+		"variant_id": req(Integer),
+	}},
+	StockAlert: {1: {
+		"id":                req(Integer),
+		"kind":              req(String),
+		"variant_id":        req(Integer),
+		"product_id":        req(Integer),
+		"seller_id":         req(Integer),
+		"sku":               req(String),
+		"stock_quantity":    req(Integer),
+		"reorder_threshold": req(Integer),
+		"created_at":        req(Timestamp),
+	}},
+	StockLowStockDigest: {1: {
+		"seller_id": req(Integer),
+		"date":      req(Date),
+		"items": reqArray(object(Schema{
+			"variant_id":        req(Integer),
+			"product_id":        req(Integer),
+			"sku":               req(String),
+			"stock_quantity":    req(Integer),
+			"reorder_threshold": req(Integer),
+		})),
+	}},
+	StockBackInStock: {1: {
+		"wave_id":           req(Integer),
+		"variant_id":        req(Integer),
+		"product_id":        req(Integer),
+		"sku":               req(String),
+		"available_to_sell": req(Integer),
+		"buyer_ids":         reqArray(req(Integer)),
+		"occurred_at":       req(Timestamp),
+	}},
+}
+
+// Lookup returns the schema of an event type and version.
+func Lookup(eventType string, version int) (Schema, bool) {
+	s, ok := schemas[eventType][version]
+	return s, ok
+}
+
+// CurrentVersion is the version publishers write for an event type, 0 if
+// the type is unknown.
+func CurrentVersion(eventType string) int {
+	latest := 0
+	for v := range schemas[eventType] {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
@@ -0,0 +1,146 @@
+// Package contracts holds the schemas of the events the services exchange
+// on the domain.events exchange. Publishers validate every payload against
+// the schema of its type and version before it leaves the service, and
+// consumers validate what they receive the same way.
+package contracts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Integer
+	Number
+	Boolean
+	Timestamp // RFC 3339 string
+	Date      // YYYY-MM-DD string
+	Object
+	Array
+)
+
+func (k Kind) String() string {
+	return [...]string{"string", "integer", "number", "boolean", "timestamp", "date", "object", "array"}[k]
+}
+
+// Field describes one JSON value. Fields that are not required may be
+// missing or null; fields a schema does not list are allowed, so adding
+// one is not a breaking change.
+type Field struct {
+	Kind     Kind
+	Required bool
+	Fields   Schema // Object
+	Elem     *Field // Array
+}
+
+// Schema describes a JSON object by its fields.
+type Schema map[string]Field
+
+func req(k Kind) Field { return Field{Kind: k, Required: true} }
+func opt(k Kind) Field { return Field{Kind: k} }
+
+func reqArray(elem Field) Field { return Field{Kind: Array, Required: true, Elem: &elem} }
+func object(s Schema) Field     { return Field{Kind: Object, Required: true, Fields: s} }
+
+// ValidationError lists every field of a payload that does not match its
+// schema.
+type ValidationError struct {
+	Type     string
+	Version  int
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s v%d: %s", e.Type, e.Version, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks a JSON payload against the schema of an event type and
+// version. A type or version without a schema is a *ValidationError too:
+// no payload can match it.
+func Validate(eventType string, version int, data []byte) error {
+	schema, ok := Lookup(eventType, version)
+	if !ok {
+		return &ValidationError{eventType, version, []string{"no schema registered"}}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{eventType, version, []string{"not valid JSON: " + err.Error()}}
+	}
+
+	var problems []string
+	check(object(schema), v, "data", &problems)
+	if len(problems) > 0 {
+		return &ValidationError{eventType, version, problems}
+	}
+	return nil
+}
+
+func check(f Field, v interface{}, path string, problems *[]string) {
+	if v == nil {
+		if f.Required {
+			*problems = append(*problems, path+" is required")
+		}
+		return
+	}
+
+	ok := true
+	switch f.Kind {
+	case String:
+		_, ok = v.(string)
+	case Integer:
+		n, isNumber := v.(json.Number)
+		_, err := n.Int64()
+		ok = isNumber && err == nil
+	case Number:
+		_, ok = v.(json.Number)
+	case Boolean:
+		_, ok = v.(bool)
+	case Timestamp:
+		s, isString := v.(string)
+		_, err := time.Parse(time.RFC3339, s)
+		ok = isString && err == nil
+	case Date:
+		s, isString := v.(string)
+		_, err := time.Parse("2006-01-02", s)
+		ok = isString && err == nil
+	case Object:
+		obj, isObject := v.(map[string]interface{})
+		if ok = isObject; ok {
+			names := make([]string, 0, len(f.Fields))
+			for name := range f.Fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				check(f.Fields[name], obj[name], path+"."+name, problems)
+			}
+		}
+	case Array:
+		items, isArray := v.([]interface{})
+		if ok = isArray; ok && f.Elem != nil {
+			for i, item := range items {
+				check(*f.Elem, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+	if !ok {
+		*problems = append(*problems, fmt.Sprintf("%s must be %s", path, an(f.Kind)))
+	}
+}
+
+func an(k Kind) string {
+	if k == Integer || k == Object || k == Array {
+		return "an " + k.String()
+	}
+	return "a " + k.String()
+}
@@ -0,0 +1,36 @@
+package contracts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		version   int
+		data      string
+		wantErr   bool
+	}{
+		{"valid", OrderCancelled, 1, `{"order_id": 12, "reason": "buyer"}`, false},
+		{"missing field", OrderCancelled, 1, `{"reason": "buyer"}`, true},
+		{"wrong kind", OrderCancelled, 1, `{"order_id": "12"}`, true},
+		{"not JSON", OrderCancelled, 1, `{`, true},
+		{"unknown type", "order.teleported", 1, `{"order_id": 12}`, true},
+		{"unknown version", OrderCancelled, 99, `{"order_id": 12}`, true},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.eventType, tt.version, []byte(tt.data))
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: got %v, want a *ValidationError", tt.name, err)
+		}
+	}
+}
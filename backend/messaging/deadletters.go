@@ -0,0 +1,147 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrNoDeadLetterQueue is returned for a queue without a .dlq.
+var ErrNoDeadLetterQueue = errors.New("messaging: no dead-letter queue for this queue")
+
+// DeadLetter is a message parked in a dead-letter queue.
+type DeadLetter struct {
+	Body          string `json:"body"`
+	OriginalQueue string `json:"original_queue"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error"`
+	FailedAt      string `json:"failed_at"`
+}
+
+// The dead-letter operations work on the .dlq of any queue on the broker,
+// not only the ones this client consumes.
+
+// openDeadLetterQueue opens a channel of its own, since declaring a queue
+// that does not exist closes the channel. It returns the queue's depth.
+func (c *Client) openDeadLetterQueue(queue string) (*amqp.Channel, int, error) {
+	ch, err := c.channel()
+	if err != nil {
+		return nil, 0, err
+	}
+	q, err := ch.QueueDeclarePassive(deadLetterQueue(queue), true, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			return nil, 0, ErrNoDeadLetterQueue
+		}
+		return nil, 0, err
+	}
+	return ch, q.Messages, nil
+}
+
+func headerString(d amqp.Delivery, name string) string {
+	s, _ := d.Headers[name].(string)
+	return s
+}
+
+// DeadLetters returns up to limit messages from the head of queue's
+// dead-letter queue without removing them, and the queue's depth.
+func (c *Client) DeadLetters(queue string, limit int) ([]DeadLetter, int, error) {
+	ch, depth, err := c.openDeadLetterQueue(queue)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer ch.Close()
+
+	letters := []DeadLetter{}
+	var last uint64
+	for len(letters) < limit {
+		d, ok, err := ch.Get(deadLetterQueue(queue), false)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			break
+		}
+		last = d.DeliveryTag
+		letters = append(letters, DeadLetter{
+			Body:          string(d.Body),
+			OriginalQueue: headerString(d, originalQueueHeader),
+			Attempts:      retryCount(d),
+			LastError:     headerString(d, lastErrorHeader),
+			FailedAt:      headerString(d, failedAtHeader),
+		})
+	}
+	if last > 0 {
+		// Put them back where they were.
+		if err := ch.Nack(last, true, true); err != nil {
+			return nil, 0, err
+		}
+	}
+	return letters, depth, nil
+}
+
+// ReplayDeadLetters moves up to limit messages from queue's dead-letter
+// queue back onto queue with a fresh retry count. Messages dead-lettered
+// while it runs are left alone.
+func (c *Client) ReplayDeadLetters(queue string, limit int) (int, error) {
+	ch, depth, err := c.openDeadLetterQueue(queue)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	if limit > depth {
+		limit = depth
+	}
+	replayed := 0
+	for replayed < limit {
+		d, ok, err := ch.Get(deadLetterQueue(queue), false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+
+		headers := amqp.Table{}
+		for k, v := range d.Headers {
+			headers[k] = v
+		}
+		delete(headers, retryCountHeader)
+
+		err = c.Publish(context.Background(), "", queue, amqp.Publishing{
+			Headers:       headers,
+			ContentType:   d.ContentType,
+			DeliveryMode:  amqp.Persistent,
+			MessageId:     d.MessageId,
+			CorrelationId: d.CorrelationId,
+			Type:          d.Type,
+			AppId:         d.AppId,
+			Timestamp:     d.Timestamp,
+			Body:          d.Body,
+		})
+		if err != nil {
+			d.Nack(false, true)
+			return replayed, err
+		}
+		if err := d.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// PurgeDeadLetters drops every message in queue's dead-letter queue.
+func (c *Client) PurgeDeadLetters(queue string) (int, error) {
+	ch, _, err := c.openDeadLetterQueue(queue)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	return ch.QueuePurge(deadLetterQueue(queue), false)
+}
@@ -0,0 +1,121 @@
+package messaging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"messaging/contracts"
+)
+
+// Exchange is the topic exchange every domain event is published to, with
+// its type as the routing key. Each service binds queues of its own to the
+// types it handles.
+const Exchange = "domain.events"
+
+// Envelope wraps every event on Exchange.
+type Envelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	CorrelationID string          `json:"correlation_id"`
+	Producer      string          `json:"producer"`
+	Data          json.RawMessage `json:"data"`
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID makes events published with ctx carry id, so the events
+// a handler publishes can be traced back to the one it received.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID carried by ctx, if any.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// newID returns a random UUID (version 4).
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func declareExchange(conn *amqp.Connection) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	return ch.ExchangeDeclare(Exchange, "topic", true, false, false, false, nil)
+}
+
+// PublishEvent validates data against the current schema of eventType,
+// wraps it in an envelope and publishes it to Exchange. An event that
+// starts a chain is its own correlation ID.
+func (c *Client) PublishEvent(ctx context.Context, eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.publishEnvelope(ctx, Envelope{
+		ID:            newID(),
+		Type:          eventType,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: CorrelationID(ctx),
+		Data:          body,
+	})
+}
+
+func (c *Client) publishEnvelope(ctx context.Context, env Envelope) error {
+	env.SchemaVersion = contracts.CurrentVersion(env.Type)
+	if err := contracts.Validate(env.Type, env.SchemaVersion, env.Data); err != nil {
+		return fmt.Errorf("messaging: invalid %s payload: %w", env.Type, err)
+	}
+	env.Producer = c.producer
+	if env.CorrelationID == "" {
+		env.CorrelationID = env.ID
+	}
+	msg, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	return c.Publish(ctx, Exchange, env.Type, amqp.Publishing{
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     env.ID,
+		CorrelationId: env.CorrelationID,
+		Type:          env.Type,
+		AppId:         c.producer,
+		Timestamp:     env.OccurredAt,
+		Body:          msg,
+	})
+}
+
+// Decode unwraps the envelope of a delivery, validates its data against
+// the schema of its type and version and unmarshals the data into v. Its
+// errors are permanent: retrying will not make the message valid.
+func Decode(d amqp.Delivery, v interface{}) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(d.Body, &env); err != nil {
+		return env, Permanent(fmt.Errorf("malformed envelope: %w", err))
+	}
+	if err := contracts.Validate(env.Type, env.SchemaVersion, env.Data); err != nil {
+		return env, Permanent(fmt.Errorf("invalid event %s: %w", env.ID, err))
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return env, Permanent(fmt.Errorf("malformed %s data: %w", env.Type, err))
+	}
+	return env, nil
+}
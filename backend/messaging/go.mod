@@ -0,0 +1,5 @@
+module messaging
+
+go 1.24.0
+
+require github.com/streadway/amqp v1.1.0
@@ -0,0 +1,136 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"messaging/contracts"
+)
+
+// OutboxEvent is an event a service wrote to its outbox table in the same
+// transaction as the change it describes. It is published under its
+// stored ID, so consumers see the same ID however often it is relayed.
+// Events with the same Key, e.g. the order they are about, are published
+// in the order they were written.
+type OutboxEvent struct {
+	ID            string
+	Key           string
+	Type          string
+	OccurredAt    time.Time
+	CorrelationID string
+	Data          json.RawMessage
+	Attempts      int
+}
+
+// Outbox is a service's outbox table, read by Relay.
+type Outbox interface {
+	// Claim returns the unsent events that are due, in the order they were
+	// written, and hides them from other relays until leaseUntil. It
+	// leaves out events whose key has an earlier event that is unsent and
+	// not due, or parked.
+	Claim(ctx context.Context, leaseUntil time.Time) ([]OutboxEvent, error)
+	// MarkSent records that an event was confirmed by the broker.
+	MarkSent(ctx context.Context, id string) error
+	// MarkFailed records a failed attempt and when to try again.
+	MarkFailed(ctx context.Context, id string, retryAt time.Time, err error) error
+	// Park records that an event can never be published, e.g. because its
+	// schema rejects it, so it is not claimed again.
+	Park(ctx context.Context, id string, err error) error
+}
+
+const (
+	relayInterval   = time.Second
+	relayLease      = 30 * time.Second
+	maxRelayBackoff = 10 * time.Minute
+)
+
+// Relay publishes the events of outbox until ctx is done. An event is
+// marked sent only after the broker confirms it, so a crash in between
+// publishes it again: delivery is at least once and consumers must
+// tolerate repeats. An event that fails holds back the later events of its
+// key, so consumers never see e.g. an order cancelled before it was
+// placed; one its schema rejects is parked for good.
+func (c *Client) Relay(ctx context.Context, outbox Outbox) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		c.relayDue(ctx, outbox)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) relayDue(ctx context.Context, outbox Outbox) {
+	events, err := outbox.Claim(ctx, time.Now().Add(relayLease))
+	if err != nil {
+		log.Printf("❌ Failed to read outbox: %v", err)
+		return
+	}
+
+	held := map[string]bool{}
+	for _, e := range events {
+		if e.Key != "" && held[e.Key] {
+			// Its lease runs out and a later claim picks it up once the
+			// earlier event is sent.
+			continue
+		}
+
+		err := c.publishEnvelope(ctx, Envelope{
+			ID:            e.ID,
+			Type:          e.Type,
+			OccurredAt:    e.OccurredAt.UTC(),
+			CorrelationID: e.CorrelationID,
+			Data:          e.Data,
+		})
+		var invalid *contracts.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			log.Printf("🅿️ Parking %s %s, its payload does not match the schema: %v", e.Type, e.ID, err)
+			if markErr := outbox.Park(ctx, e.ID, err); markErr != nil {
+				log.Printf("❌ Failed to park outbox event %s: %v", e.ID, markErr)
+			}
+			held[e.Key] = true
+			continue
+		case err != nil:
+			retryAt := time.Now().Add(relayBackoff(e.Attempts))
+			log.Printf("🔁 Failed to publish %s %s (attempt %d), retrying at %s: %v", e.Type, e.ID, e.Attempts+1, retryAt.Format(time.RFC3339), err)
+			if markErr := outbox.MarkFailed(ctx, e.ID, retryAt, err); markErr != nil {
+				log.Printf("❌ Failed to record outbox attempt for %s: %v", e.ID, markErr)
+			}
+			// The rest of the batch would fail the same way; their
+			// lease runs out and the next claim picks them up.
+			if errors.Is(err, ErrNotConnected) {
+				return
+			}
+			held[e.Key] = true
+			continue
+		}
+
+		if err := outbox.MarkSent(ctx, e.ID); err != nil {
+			// Published but not marked: it goes out again once its lease
+			// runs out.
+			log.Printf("❌ Failed to mark outbox event %s sent: %v", e.ID, err)
+		}
+	}
+}
+
+// relayBackoff doubles from one second with each failed attempt, up to
+// maxRelayBackoff.
+func relayBackoff(attempts int) time.Duration {
+	if attempts >= 10 {
+		return maxRelayBackoff
+	}
+	d := time.Second << uint(attempts)
+	if d > maxRelayBackoff {
+		d = maxRelayBackoff
+	}
+	return d
+}
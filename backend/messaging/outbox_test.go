@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeOutbox records what the relay does with the events it claims.
+type fakeOutbox struct {
+	events []OutboxEvent
+	sent   []string
+	failed []string
+	parked []string
+}
+
+func (o *fakeOutbox) Claim(ctx context.Context, leaseUntil time.Time) ([]OutboxEvent, error) {
+	return o.events, nil
+}
+
+func (o *fakeOutbox) MarkSent(ctx context.Context, id string) error {
+	o.sent = append(o.sent, id)
+	return nil
+}
+
+func (o *fakeOutbox) MarkFailed(ctx context.Context, id string, retryAt time.Time, err error) error {
+	o.failed = append(o.failed, id)
+	return nil
+}
+
+func (o *fakeOutbox) Park(ctx context.Context, id string, err error) error {
+	o.parked = append(o.parked, id)
+	return nil
+}
+
+func TestRelayParksUnknownEventAndHoldsItsKey(t *testing.T) {
+	outbox := &fakeOutbox{events: []OutboxEvent{
+		{ID: "a", Key: "7", Type: "order.teleported", Data: []byte(`{"order_id": 7}`)},
+		{ID: "b", Key: "7", Type: "order.cancelled", Data: []byte(`{"order_id": 7}`)},
+	}}
+
+	(&Client{}).relayDue(context.Background(), outbox)
+
+	if len(outbox.parked) != 1 || outbox.parked[0] != "a" {
+		t.Errorf("parked %v, want [a]", outbox.parked)
+	}
+	if len(outbox.failed) != 0 {
+		t.Errorf("failed %v, want none: a schema failure is not retried", outbox.failed)
+	}
+	if len(outbox.sent) != 0 {
+		t.Errorf("sent %v, want none: b must wait for a", outbox.sent)
+	}
+}
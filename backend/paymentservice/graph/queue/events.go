@@ -0,0 +1,8 @@
+package queue
+
+// OrderRefunded is the payment.refunded event that tells InventoryService
+// to put back the stock of a refunded order.
+type OrderRefunded struct {
+	OrderID   int `json:"order_id"`
+	PaymentID int `json:"payment_id"`
+}
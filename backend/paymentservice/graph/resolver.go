@@ -2,11 +2,14 @@ package graph
 
 import (
 	"database/sql"
+
+	"messaging"
 )
 
 // Resolver serves as dependency injection container.
 type Resolver struct {
-	DB *sql.DB
+	DB     *sql.DB
+	Broker *messaging.Client
 }
 
 
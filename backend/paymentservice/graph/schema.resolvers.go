@@ -7,6 +7,8 @@ package graph
 import (
 	"PaymentService/graph/queue"
 	"PaymentService/graph/model"
+	"PaymentService/outbox"
+	"messaging/contracts"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -93,19 +95,39 @@ func (r *mutationResolver) RefundPayment(ctx context.Context, paymentID string)
 		return nil, fmt.Errorf("invalid payment ID: %v", err)
 	}
 
-	_, err = r.DB.ExecContext(ctx, `
+	// The refund and the payment.refunded event that puts the order's stock
+	// back commit together; InventoryService ignores repeats.
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("refund failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	var orderID int
+	err = tx.QueryRowContext(ctx, `
 		UPDATE payments SET payment_status = 'refunded', updated_at = $1
 		WHERE id = $2
-	`, now, id)
+		RETURNING order_id
+	`, now, id).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("payment %d not found", id)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("refund failed: %v", err)
 	}
 
-	_, _ = r.DB.ExecContext(ctx, `
+	_, _ = tx.ExecContext(ctx, `
 		INSERT INTO payment_logs (payment_id, status, message)
 		VALUES ($1, 'refunded', 'Payment refunded')
 	`, id)
 
+	if err := outbox.Add(ctx, tx, contracts.PaymentRefunded, queue.OrderRefunded{OrderID: orderID, PaymentID: id}); err != nil {
+		return nil, fmt.Errorf("refund failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("refund failed: %v", err)
+	}
+
 	return r.getPaymentByID(ctx, id)
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"log"
@@ -8,9 +9,13 @@ import (
     "os"
     "github.com/joho/godotenv"
 
+	"PaymentService/auth"
 	"PaymentService/graph"
 	"PaymentService/rabbitmq"
     "PaymentService/cod"
+	"PaymentService/outbox"
+	"messaging"
+	"messaging/contracts"
 
 	_ "github.com/lib/pq"
 	"github.com/99designs/gqlgen/graphql/handler"
@@ -38,9 +43,13 @@ func main() {
 	}
 	defer db.Close()
 
-	resolver := &graph.Resolver{DB: db}
+	broker := messaging.New("payment-service", messaging.URL())
+	resolver := &graph.Resolver{DB: db, Broker: broker}
 	rabbitmq.StartConsumer(resolver)
 
+	// Publish the payment events written to the outbox
+	go broker.Relay(context.Background(), outbox.Store{DB: db})
+
 	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{
 		Resolvers: resolver,
 	}))
@@ -62,6 +71,9 @@ func main() {
 	http.Handle("/query", corsHandler(srv))
     http.HandleFunc("/cod-paid", cod.CODPaidHandler(db))
 
+	// Dead-lettered messages of any consumer queue on the broker
+	http.Handle("/admin/dead-letters/", auth.RequireAdmin(broker.DeadLetterHandler("/admin/dead-letters")))
+
 	// Webhook endpoint from Hasura
 	http.HandleFunc("/publish-order-created", func(w http.ResponseWriter, r *http.Request) {
         if r.Method != http.MethodPost {
@@ -98,17 +110,19 @@ func main() {
             PaymentProvider: input.Event.Data.New.PaymentProvider,
         }
     
-        log.Printf("📨 Received and forwarded order_created: %+v", msg)
+        log.Printf("📨 Received order_created: %+v", msg)
     
-        if err := rabbitmq.PublishOrderCreated(msg); err != nil {
-            log.Printf("❌ Failed to publish order_created: %v", err)
-            http.Error(w, "Failed to publish message", http.StatusInternalServerError)
+        // Non-2xx makes Hasura retry the delivery; once the event is in
+        // the outbox the relay publishes it.
+        if err := outbox.Add(r.Context(), db, contracts.PaymentRequested, msg); err != nil {
+            log.Printf("❌ Failed to queue payment.requested: %v", err)
+            http.Error(w, "Failed to queue message", http.StatusInternalServerError)
             return
         }
     
-        log.Printf("✅ Message published to order_created: %+v", msg)
+        log.Printf("✅ Order created event queued as payment.requested: %+v", msg)
         w.WriteHeader(http.StatusOK)
-        w.Write([]byte("Message published"))
+        w.Write([]byte("Message queued"))
     })
 
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
@@ -0,0 +1,19 @@
+-- Transactional outbox: payment events are written in the same transaction
+-- as the payment change they describe and published by the relay.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(), -- the published event ID
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    correlation_id TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending
+    ON outbox_events (next_attempt_at)
+    WHERE sent_at IS NULL;
@@ -0,0 +1,137 @@
+// Package outbox reads and writes paymentdb's outbox_events table. Events
+// are added in the transaction of the payment change they describe and
+// published by messaging's relay.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"messaging"
+	"messaging/contracts"
+)
+
+// claimBatch caps the events one relay pass publishes.
+const claimBatch = 100
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Add writes an event to the outbox through db, the *sql.Tx of the change
+// it describes. The payload is checked against the event's schema first, so
+// an event the relay could never publish fails the transaction instead.
+func Add(ctx context.Context, db execer, eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := contracts.Validate(eventType, contracts.CurrentVersion(eventType), body); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", eventType, err)
+	}
+
+	var correlationID *string
+	if id := messaging.CorrelationID(ctx); id != "" {
+		correlationID = &id
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO outbox_events (event_type, payload, correlation_id)
+		VALUES ($1, $2, $3)
+	`, eventType, string(body), correlationID)
+	return err
+}
+
+// Store is paymentdb's outbox as seen by messaging.Relay.
+type Store struct {
+	DB *sql.DB
+}
+
+// Claim pushes the next attempt of a batch of due events out to
+// leaseUntil. SKIP LOCKED keeps concurrent relays off each other's rows,
+// and an event waits while an earlier one of its order is unsent and not
+// claimed with it.
+func (s Store) Claim(ctx context.Context, leaseUntil time.Time) ([]messaging.OutboxEvent, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		UPDATE outbox_events SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT o.id FROM outbox_events o
+			WHERE o.sent_at IS NULL AND o.parked_at IS NULL AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events e
+				WHERE e.order_id = o.order_id AND e.seq < o.seq AND e.sent_at IS NULL
+				AND (e.parked_at IS NOT NULL OR e.next_attempt_at > NOW())
+			)
+			ORDER BY o.seq
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, order_id, seq, event_type, payload, correlation_id, occurred_at, attempts
+	`, leaseUntil, claimBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type claimed struct {
+		event messaging.OutboxEvent
+		seq   int64
+	}
+	var batch []claimed
+	for rows.Next() {
+		var c claimed
+		var orderID sql.NullInt64
+		var payload []byte
+		var correlationID sql.NullString
+		if err := rows.Scan(&c.event.ID, &orderID, &c.seq, &c.event.Type, &payload, &correlationID, &c.event.OccurredAt, &c.event.Attempts); err != nil {
+			return nil, err
+		}
+		if orderID.Valid {
+			c.event.Key = strconv.FormatInt(orderID.Int64, 10)
+		}
+		c.event.Data = payload
+		c.event.CorrelationID = correlationID.String
+		batch = append(batch, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(batch, func(i, j int) bool { return batch[i].seq < batch[j].seq })
+	events := make([]messaging.OutboxEvent, len(batch))
+	for i, c := range batch {
+		events[i] = c.event
+	}
+	return events, nil
+}
+
+func (s Store) MarkSent(ctx context.Context, id string) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE outbox_events SET sent_at = NOW(), last_error = NULL
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (s Store) MarkFailed(ctx context.Context, id string, retryAt time.Time, cause error) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+		WHERE id = $1
+	`, id, retryAt, cause.Error())
+	return err
+}
+
+// Park takes an event the relay can never publish out of the outbox for
+// good, keeping the reason in last_error.
+func (s Store) Park(ctx context.Context, id string, cause error) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE outbox_events SET parked_at = NOW(), last_error = $2
+		WHERE id = $1
+	`, id, cause.Error())
+	return err
+}
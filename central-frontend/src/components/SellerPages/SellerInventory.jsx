@@ -43,7 +43,6 @@ const Inventory = () => {
     size: '',
     color: '',
     price: '',
-    stockQuantity: '',
     image: '',
   });
   const [selectedVariant, setSelectedVariant] = useState(null); // For editing variant
@@ -157,7 +156,7 @@ const Inventory = () => {
   // Variant Handlers
   const handleCreateVariant = async (e) => {
     e.preventDefault();
-    const { variantName, size, color, stockQuantity, image } = variantDetails;
+    const { variantName, size, color, image } = variantDetails;
     try {
       await createVariant({
         variables: {
@@ -165,7 +164,6 @@ const Inventory = () => {
           variantName,
           size,
           color,
-          stockQuantity: parseInt(stockQuantity, 10),
           image: image || "",
         },
       });
@@ -173,7 +171,6 @@ const Inventory = () => {
         variantName: '',
         size: '',
         color: '',
-        stockQuantity: '',
         image: '',
       });
       setVariantModalOpen(false);
@@ -184,7 +181,7 @@ const Inventory = () => {
 
   const handleUpdateVariant = async (e) => {
     e.preventDefault();
-    const { variantName, size, color, stockQuantity, image } = variantDetails;
+    const { variantName, size, color, image } = variantDetails;
     try {
       await updateVariant({
         variables: {
@@ -192,7 +189,6 @@ const Inventory = () => {
           variantName,
           size,
           color,
-          stockQuantity: parseInt(stockQuantity, 10),
           image: image || "",
         },
       });
@@ -523,7 +519,6 @@ const Inventory = () => {
                               variantName: variant.variant_name,
                               size: variant.size,
                               color: variant.color,
-                              stockQuantity: variant.stock_quantity,
                               image: variant.image,
                             });
                             setVariantEditModalOpen(true);
@@ -615,15 +610,6 @@ const Inventory = () => {
                   onChange={handleVariantChange}
                   required
                 />
-                Variant Stock Quantity:
-                <input
-                  type="number"
-                  name="stockQuantity"
-                  placeholder="Stock Quantity"
-                  value={variantDetails.stockQuantity}
-                  onChange={handleVariantChange}
-                  required
-                />
                 <button type="submit" className="sellerinv-btn sellerinv-btn-primary">
                   Create Variant
                 </button>
@@ -666,14 +652,6 @@ const Inventory = () => {
               onChange={handleVariantChange}
               required
             />
-            <input
-              type="number"
-              name="stockQuantity"
-              placeholder="Stock Quantity"
-              value={variantDetails.stockQuantity}
-              onChange={handleVariantChange}
-              required
-            />
             Variant Image URL:
             <input
               type="text"
@@ -82,7 +82,6 @@ export const CREATE_VARIANT = gql`
     $variantName: String!
     $size: String!
     $color: String!
-    $stockQuantity: Int!
     $image: String!
   ) {
     insert_product_variants(
@@ -91,7 +90,6 @@ export const CREATE_VARIANT = gql`
         variant_name: $variantName
         size: $size
         color: $color
-        stock_quantity: $stockQuantity
         image: $image
       }
     ) {
@@ -115,7 +113,6 @@ export const UPDATE_VARIANT = gql`
     $variantName: String
     $size: String
     $color: String
-    $stockQuantity: Int
     $image: String!
   ) {
     update_product_variants(
@@ -124,7 +121,6 @@ export const UPDATE_VARIANT = gql`
         variant_name: $variantName
         size: $size
         color: $color
-        stock_quantity: $stockQuantity
         image: $image
       }
     ) {